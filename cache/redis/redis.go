@@ -3,9 +3,9 @@ package redis
 import "github.com/go-redis/redis"
 
 type Config struct {
-	Address  string `mapstructure:"address"`
-	Password string `mapstructure:"password"`
-	Database int    `mapstructure:"database"`
+	Address  string `mapstructure:"address" validate:"required,hostport" desc:"Address of the redis server as host:port"`
+	Password string `mapstructure:"password" secret:"true" desc:"Password of the redis server"`
+	Database int    `mapstructure:"database" validate:"min=0" desc:"Index of the redis database"`
 }
 
 func NewClient(config *Config) (*redis.Client, error) {
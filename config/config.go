@@ -1,36 +1,107 @@
 package config
 
 import (
+	"io/fs"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/PlanckProject/go-commons/logger"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
-func Parse(config interface{}, path string) {
-	v := viper.New()
+type (
+	// Option configures how Load reads the configuration
+	Option func(*options)
 
-	logger.Infof("Config path: %s", path)
-	configDir := filepath.Dir(path)
-	filename := filepath.Base(path)
+	options struct {
+		envEnabled  bool
+		envPrefix   string
+		overlays    []string
+		profile     string
+		format      string
+		fsys        fs.FS
+		providers   []Provider
+		flags       *pflag.FlagSet
+		unknownKeys UnknownKeys
 
-	filenameTokens := strings.Split(filename, ".")
+		secretResolvers map[string]SecretResolver
+	}
+)
+
+// envKeyReplacer maps nested keys such as logger.max_size onto LOGGER_MAX_SIZE
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// WithEnvPrefix overlays environment variables on top of the config file.
+// A key such as logger.level is read from PREFIX_LOGGER_LEVEL, or from
+// LOGGER_LEVEL if prefix is empty. Every key of cfg is looked up, including
+// the ones absent from the file.
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envEnabled = true
+		o.envPrefix = prefix
+	}
+}
 
-	v.AddConfigPath(configDir)
-	v.SetConfigName(filenameTokens[0])
-	v.SetConfigType(filenameTokens[1])
+// WithOverlays reads the given files after the base config file, in order.
+// Maps are merged deeply, any other value, lists included, is replaced by
+// the last file that sets it.
+func WithOverlays(paths ...string) Option {
+	return func(o *options) {
+		o.overlays = append(o.overlays, paths...)
+	}
+}
+
+// WithProfile reads the profile variant of the config file right after it,
+// such as config.production.yaml for config.yaml and the production profile.
+// The profile file must exist. An empty profile is ignored, so that it can be
+// taken from the environment as is.
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
 
-	err := v.ReadInConfig()
+// Parse reads the config file at path into config and exits the process on failure.
+// Use Load to handle the errors instead.
+func Parse(config interface{}, path string, opts ...Option) {
+	loader := NewLoader(path, opts...)
+	err := loader.Load(config)
 	if err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+		if _, ok := err.(*FileNotFoundError); ok {
 			logger.Fatalln("Config not found in directory")
 		} else {
 			logger.Fatalln(err)
 		}
 	}
-	err = v.Unmarshal(config)
-	if err != nil {
-		logger.Fatalln(err)
+
+	logger.WithField("config", loader.Effective()).Infof("Config loaded from %s", path)
+}
+
+// Load reads the config file at path, followed by any overlays, into cfg.
+// It returns a *FileNotFoundError when a file is missing, a *ParseError when
+// one is malformed, one or more *DecodeError when values don't fit cfg and
+// a *ValidationError when they break the rules of its validate tags.
+func Load(cfg interface{}, path string, opts ...Option) error {
+	return NewLoader(path, opts...).Load(cfg)
+}
+
+// profilePath inserts the profile before the extension of path
+func profilePath(path, profile string) string {
+	extension := filepath.Ext(path)
+	return strings.TrimSuffix(path, extension) + "." + profile + extension
+}
+
+func bindEnv(v *viper.Viper, prefix string, cfg interface{}) {
+	if prefix != "" {
+		v.SetEnvPrefix(prefix)
 	}
+	v.SetEnvKeyReplacer(envKeyReplacer)
+	v.AutomaticEnv()
+
+	// AutomaticEnv only applies to keys viper already knows about
+	walkFields(reflect.TypeOf(cfg), "", func(key string, _ reflect.StructField) {
+		v.BindEnv(key)
+	})
 }
@@ -0,0 +1,78 @@
+package defaults
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/PlanckProject/go-commons/config/hooks"
+	"github.com/mitchellh/mapstructure"
+)
+
+// TagName is the struct tag holding the default value of a field
+const TagName = "default"
+
+// Apply sets every zero-valued field of the struct cfg points to from its
+// default tag, descending into nested structs. Values are decoded with the
+// hooks of the config package, so "30s" fills a time.Duration and "a,b" a []string.
+// A zero value is indistinguishable from an unset one, so a field can't be
+// kept at false or 0 when its default is something else.
+func Apply(cfg interface{}) error {
+	value := reflect.ValueOf(cfg)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("Defaults can only be applied through a non-nil pointer, got %T", cfg)
+	}
+	return applyStruct(value.Elem(), value.Elem().Type().Name())
+}
+
+func applyStruct(value reflect.Value, path string) error {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fieldValue := value.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldPath := path + "." + field.Name
+
+		if defaultValue, ok := field.Tag.Lookup(TagName); ok && defaultValue != "" && isZero(fieldValue) {
+			if err := decode(defaultValue, fieldValue.Addr().Interface()); err != nil {
+				return fmt.Errorf("Invalid default for %s: %v", fieldPath, err)
+			}
+		}
+
+		if err := applyStruct(fieldValue, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decode(input string, output interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           output,
+		WeaklyTypedInput: true,
+		DecodeHook:       hooks.DecodeHook(),
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
+
+func isZero(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SourceDefault is the source of values taken from default tags
+	SourceDefault = "default"
+	// SourceEnvPrefix prefixes the variable name in the source of values read from the environment
+	SourceEnvPrefix = "env:"
+	// SourceFlagPrefix prefixes the flag name in the source of values read from the command line
+	SourceFlagPrefix = "flag:--"
+
+	secretTagName = "secret"
+)
+
+// Setting is a single key of the effective configuration with the source
+// its value was taken from: SourceDefault, the name of a Provider such as a
+// file path or a URL, an environment variable or a flag
+type Setting struct {
+	Key    string      `json:"key" yaml:"key"`
+	Value  interface{} `json:"value" yaml:"value"`
+	Source string      `json:"source" yaml:"source"`
+}
+
+// Dump returns the settings applied by the last successful Load, nested by
+// key, with secret values redacted. Values resolved from a secret reference
+// and fields tagged secret:"true" are secret.
+func (l *Loader) Dump() map[string]interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return redactSettings(l.settings, l.secrets, "")
+}
+
+// Effective returns every setting applied by the last successful Load,
+// sorted by key, with secret values redacted
+func (l *Loader) Effective() []Setting {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var settings []Setting
+	flattenSettings(redactSettings(l.settings, l.secrets, ""), "", func(key string, value interface{}) {
+		settings = append(settings, Setting{Key: key, Value: value, Source: l.sources[key]})
+	})
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// DumpJSON returns Effective encoded as JSON
+func (l *Loader) DumpJSON() ([]byte, error) {
+	return json.MarshalIndent(jsonCompatible(l.Effective()), "", "  ")
+}
+
+// DumpYAML returns Effective encoded as YAML
+func (l *Loader) DumpYAML() ([]byte, error) {
+	return yaml.Marshal(l.Effective())
+}
+
+// Handler serves the effective configuration, as JSON or as YAML when
+// the format query parameter is yaml. It's meant for debug endpoints.
+func (l *Loader) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var content []byte
+		var err error
+
+		if r.URL.Query().Get("format") == "yaml" {
+			w.Header().Set("Content-Type", "application/x-yaml")
+			content, err = l.DumpYAML()
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			content, err = l.DumpJSON()
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(content)
+	})
+}
+
+// sourcesOf returns the source of every leaf of settings. Flags win over the
+// environment, the environment over the files and the files over defaults,
+// whether from default tags or from flags left unset.
+func (l *Loader) sourcesOf(settings map[string]interface{}, origins map[string]string) map[string]string {
+	sources := make(map[string]string)
+	flattenSettings(settings, "", func(key string, _ interface{}) {
+		for _, candidate := range keyAndParents(key) {
+			if source := l.overrideSource(candidate); source != "" {
+				sources[key] = source
+				return
+			}
+		}
+		if origin, ok := origins[key]; ok {
+			sources[key] = origin
+			return
+		}
+		sources[key] = SourceDefault
+	})
+	return sources
+}
+
+// overrideSource returns the flag or environment variable that set key, if any
+func (l *Loader) overrideSource(key string) string {
+	if l.options.flags != nil {
+		if flag := l.options.flags.Lookup(key); flag != nil && flag.Changed {
+			return SourceFlagPrefix + key
+		}
+	}
+	if l.options.envEnabled {
+		name := envName(l.options.envPrefix, key)
+		if _, ok := os.LookupEnv(name); ok {
+			return SourceEnvPrefix + name
+		}
+	}
+	return ""
+}
+
+// secretKeys adds the keys of cfg tagged secret:"true" to secrets
+func secretKeys(cfg interface{}, secrets map[string]bool) {
+	walkFields(reflect.TypeOf(cfg), "", func(key string, field reflect.StructField) {
+		if field.Tag.Get(secretTagName) == "true" {
+			secrets[key] = true
+		}
+	})
+}
+
+func envName(prefix, key string) string {
+	if prefix != "" {
+		key = prefix + "_" + key
+	}
+	return strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+func keyAndParents(key string) []string {
+	keys := []string{key}
+	for index := strings.LastIndex(key, "."); index > 0; index = strings.LastIndex(key, ".") {
+		key = key[:index]
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func flattenSettings(settings map[string]interface{}, prefix string, fn func(key string, value interface{})) {
+	for key, value := range settings {
+		fullKey := joinKey(prefix, key)
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenSettings(nested, fullKey, fn)
+			continue
+		}
+		fn(fullKey, value)
+	}
+}
+
+// jsonCompatible converts the map[interface{}]interface{} values the YAML
+// parser produces into maps encoding/json can marshal
+func jsonCompatible(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case []Setting:
+		settings := make([]Setting, len(typedValue))
+		for i, setting := range typedValue {
+			setting.Value = jsonCompatible(setting.Value)
+			settings[i] = setting
+		}
+		return settings
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typedValue))
+		for key, item := range typedValue {
+			converted[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(typedValue))
+		for key, item := range typedValue {
+			converted[key] = jsonCompatible(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(typedValue))
+		for i, item := range typedValue {
+			converted[i] = jsonCompatible(item)
+		}
+		return converted
+	}
+	return value
+}
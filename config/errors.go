@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"go.uber.org/multierr"
+)
+
+var (
+	parseErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+	decodeErrorKeyPattern = regexp.MustCompile(`'([^']*)'`)
+)
+
+// parseErrorPrefix is prepended by viper to every parser error
+const parseErrorPrefix = "While parsing config: "
+
+// FileNotFoundError is returned when the configuration file does not exist
+type FileNotFoundError struct {
+	Path string
+}
+
+func (e *FileNotFoundError) Error() string {
+	return fmt.Sprintf("Config file not found: %s", e.Path)
+}
+
+// ParseError is returned when the configuration file is not valid for its format.
+// Line is 0 when the underlying parser does not report it.
+type ParseError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	message := strings.TrimPrefix(e.Err.Error(), parseErrorPrefix)
+	if e.Line > 0 {
+		return fmt.Sprintf("Failed to parse config %s at line %d: %s", e.Path, e.Line, message)
+	}
+	return fmt.Sprintf("Failed to parse config %s: %s", e.Path, message)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned when a configuration value cannot be decoded into
+// the target struct. Key is the mapstructure path of the offending value.
+type DecodeError struct {
+	Key     string
+	Message string
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Failed to decode config: %s", e.Message)
+}
+
+func newParseError(path string, err error) *ParseError {
+	parseErr := &ParseError{Path: path, Err: err}
+	if match := parseErrorLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		parseErr.Line, _ = strconv.Atoi(match[1])
+	}
+	return parseErr
+}
+
+// newDecodeError splits a mapstructure error into one DecodeError per key.
+// Use multierr.Errors to get the individual errors back.
+func newDecodeError(err error) error {
+	mapstructureErr, ok := err.(*mapstructure.Error)
+	if !ok {
+		return &DecodeError{Message: err.Error()}
+	}
+
+	var result error
+	for _, message := range mapstructureErr.Errors {
+		decodeErr := &DecodeError{Message: message}
+		if match := decodeErrorKeyPattern.FindStringSubmatch(message); match != nil {
+			decodeErr.Key = match[1]
+		}
+		result = multierr.Append(result, decodeErr)
+	}
+	return result
+}
+
+// UnknownKeyError is reported for a key that matches no field of the config.
+// Source is the provider the key was read from, Suggestion the closest valid
+// key when one is close enough to be a typo.
+type UnknownKeyError struct {
+	Key        string
+	Source     string
+	Suggestion string
+}
+
+func (e *UnknownKeyError) Error() string {
+	message := fmt.Sprintf("Unknown config key %s", e.Key)
+	if e.Source != "" {
+		message += fmt.Sprintf(" in %s", e.Source)
+	}
+	if e.Suggestion != "" {
+		message += fmt.Sprintf(", did you mean %s?", e.Suggestion)
+	}
+	return message
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/PlanckProject/go-commons/config/hooks"
+)
+
+const tagName = "mapstructure"
+
+// walkFields calls fn for every leaf field of the struct type t with its
+// dotted mapstructure key. Nested structs are descended into, squashed
+// structs share the key of their parent.
+func walkFields(t reflect.Type, prefix string, fn func(key string, field reflect.StructField)) {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	eachField(t, func(name string, field reflect.StructField) {
+		key := joinKey(prefix, name)
+		if isNestedStruct(field.Type) {
+			walkFields(field.Type, key, fn)
+			return
+		}
+		fn(key, field)
+	})
+}
+
+// eachField calls fn for every field of the struct type t with its
+// mapstructure name, expanding squashed structs in place
+func eachField(t reflect.Type, fn func(name string, field reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, squash := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		if squash {
+			eachField(indirectType(field.Type), fn)
+			continue
+		}
+		fn(name, field)
+	}
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func fieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get(tagName)
+	tokens := strings.Split(tag, ",")
+	squash := false
+	for _, token := range tokens[1:] {
+		if token == "squash" {
+			squash = true
+		}
+	}
+	if tokens[0] == "" {
+		return strings.ToLower(field.Name), squash
+	}
+	return strings.ToLower(tokens[0]), squash
+}
+
+func isNestedStruct(t reflect.Type) bool {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	// Structs decoded from a string are leaves
+	return !hooks.Decodes(t)
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
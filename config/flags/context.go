@@ -0,0 +1,53 @@
+package flags
+
+import "context"
+
+// Well known attributes flags are evaluated against
+const (
+	AttributeUser    = "user"
+	AttributeTenant  = "tenant"
+	AttributeRequest = "request"
+)
+
+type (
+	// Attributes describe the subject flags are evaluated for, such as its user or tenant
+	Attributes map[string]string
+
+	attributesKey struct{}
+)
+
+// WithAttributes returns a copy of ctx carrying attributes on top of the ones it already carries
+func WithAttributes(ctx context.Context, attributes Attributes) context.Context {
+	merged := make(Attributes)
+	for key, value := range AttributesFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range attributes {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, attributesKey{}, merged)
+}
+
+// WithUser returns a copy of ctx carrying the user attribute
+func WithUser(ctx context.Context, user string) context.Context {
+	return WithAttributes(ctx, Attributes{AttributeUser: user})
+}
+
+// WithTenant returns a copy of ctx carrying the tenant attribute
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return WithAttributes(ctx, Attributes{AttributeTenant: tenant})
+}
+
+// WithRequest returns a copy of ctx carrying the request attribute
+func WithRequest(ctx context.Context, request string) context.Context {
+	return WithAttributes(ctx, Attributes{AttributeRequest: request})
+}
+
+// AttributesFromContext returns the attributes carried by ctx. They must not be modified.
+func AttributesFromContext(ctx context.Context) Attributes {
+	if ctx == nil {
+		return nil
+	}
+	attributes, _ := ctx.Value(attributesKey{}).(Attributes)
+	return attributes
+}
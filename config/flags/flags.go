@@ -0,0 +1,234 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultAttribute is the attribute rollouts and variants are bucketed by
+// when a flag doesn't name one
+const DefaultAttribute = AttributeUser
+
+type (
+	// Config declares flags by name. It's read from the same files as the rest
+	// of the configuration by embedding it in the config struct:
+	//
+	//	type AppConfig struct {
+	//		Flags flags.Config `mapstructure:"flags"`
+	//	}
+	//
+	// and declaring the flags under that key:
+	//
+	//	flags:
+	//	  new_checkout:
+	//	    enabled: true
+	//	    rollout: 25
+	//	  ranking:
+	//	    enabled: true
+	//	    attribute: tenant
+	//	    variants: {control: 50, bm25: 50}
+	//	  beta:
+	//	    rules:
+	//	      - attribute: tenant
+	//	        values: [acme]
+	//	        enabled: true
+	//
+	// Config keys are case insensitive and nested on dots, so flag names are
+	// looked up in lowercase and can't contain dots. A dotted name in a file
+	// turns into an unknown key, reported by config.WithUnknownKeys.
+	Config map[string]Flag
+
+	// Flag is a boolean flag when only Enabled is set, a percentage flag when
+	// Rollout is set and a variant flag when Variants are set
+	Flag struct {
+		Enabled   bool            `mapstructure:"enabled" desc:"Turns the flag on"`
+		Rollout   *float64        `mapstructure:"rollout" desc:"Percentage of subjects the flag is on for, all of them when unset"`
+		Attribute string          `mapstructure:"attribute" desc:"Attribute identifying the subjects of rollouts and variants, user when empty"`
+		Variants  map[string]uint `mapstructure:"variants" desc:"Weight of every variant"`
+		Rules     []Rule          `mapstructure:"rules" desc:"Overrides for subjects with given attributes, the first match wins"`
+	}
+
+	// Rule overrides a flag for the contexts whose Attribute is one of Values.
+	// Variant forces a variant of an enabled variant flag.
+	Rule struct {
+		Attribute string   `mapstructure:"attribute" desc:"Attribute to match"`
+		Values    []string `mapstructure:"values" desc:"Values of the attribute the rule applies to"`
+		Enabled   bool     `mapstructure:"enabled" desc:"Turns the flag on or off for the matching subjects"`
+		Variant   string   `mapstructure:"variant" desc:"Variant picked for the matching subjects"`
+	}
+
+	// Set evaluates flags against the attributes of a context. It's safe for
+	// concurrent use and can be updated while in use. The flags follow config
+	// reloads when the set is updated from a config.Watcher:
+	//
+	//	set, err := flags.NewSet(cfg.Flags)
+	//	watcher.Subscribe(func(old, new *AppConfig) {
+	//		if err := set.Update(new.Flags); err != nil {
+	//			logger.WithField("error", err).Error("Failed to update flags")
+	//		}
+	//	})
+	Set struct {
+		mu     sync.RWMutex
+		config Config
+	}
+)
+
+// NewSet returns a Set evaluating the flags of config
+func NewSet(config Config) (*Set, error) {
+	s := &Set{}
+	if err := s.Update(config); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update replaces the flags of the set. Invalid flags are rejected and the
+// previous ones are kept.
+func (s *Set) Update(config Config) error {
+	normalized := make(Config, len(config))
+	for name, flag := range config {
+		if strings.Contains(name, ".") {
+			return fmt.Errorf("Invalid flag %s: names can't contain dots", name)
+		}
+		if err := flag.validate(); err != nil {
+			return fmt.Errorf("Invalid flag %s: %w", name, err)
+		}
+
+		lowered := strings.ToLower(name)
+		if _, ok := normalized[lowered]; ok {
+			return fmt.Errorf("Invalid flag %s: declared twice with different cases", name)
+		}
+		normalized[lowered] = flag
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = normalized
+	return nil
+}
+
+// Enabled tells whether the flag name, in any case, is on for the
+// attributes of ctx. Unknown flags are off.
+func (s *Set) Enabled(ctx context.Context, name string) bool {
+	enabled, _ := s.evaluate(ctx, name)
+	return enabled
+}
+
+// Variant returns the variant of the flag name picked for the attributes of
+// ctx. It's empty when the flag is off, unknown or has no variants.
+func (s *Set) Variant(ctx context.Context, name string) string {
+	_, variant := s.evaluate(ctx, name)
+	return variant
+}
+
+func (s *Set) evaluate(ctx context.Context, name string) (bool, string) {
+	name = strings.ToLower(name)
+
+	s.mu.RLock()
+	flag, ok := s.config[name]
+	s.mu.RUnlock()
+	if !ok {
+		return false, ""
+	}
+	return flag.evaluate(name, AttributesFromContext(ctx))
+}
+
+func (f Flag) evaluate(name string, attributes Attributes) (bool, string) {
+	subject := attributes[f.attribute()]
+
+	for _, rule := range f.Rules {
+		if !rule.matches(attributes) {
+			continue
+		}
+		if !rule.Enabled {
+			return false, ""
+		}
+		if rule.Variant != "" {
+			return true, rule.Variant
+		}
+		return true, f.variant(name, subject)
+	}
+
+	if !f.Enabled {
+		return false, ""
+	}
+	if f.Rollout != nil && *f.Rollout < 100 {
+		// Subjects can't be bucketed consistently without an identity
+		if subject == "" || bucket(name+":rollout", subject, 10000) >= uint64(*f.Rollout*100) {
+			return false, ""
+		}
+	}
+	return true, f.variant(name, subject)
+}
+
+// variant picks a variant by weight, always the same one for a subject
+func (f Flag) variant(name, subject string) string {
+	var total uint64
+	names := make([]string, 0, len(f.Variants))
+	for variant, weight := range f.Variants {
+		total += uint64(weight)
+		names = append(names, variant)
+	}
+	if total == 0 {
+		return ""
+	}
+	sort.Strings(names)
+
+	position := bucket(name+":variant", subject, total)
+	for _, variant := range names {
+		weight := uint64(f.Variants[variant])
+		if position < weight {
+			return variant
+		}
+		position -= weight
+	}
+	return ""
+}
+
+func (f Flag) attribute() string {
+	if f.Attribute == "" {
+		return DefaultAttribute
+	}
+	return f.Attribute
+}
+
+func (f Flag) validate() error {
+	if f.Rollout != nil && (*f.Rollout < 0 || *f.Rollout > 100) {
+		return fmt.Errorf("Rollout must be between 0 and 100, got %v", *f.Rollout)
+	}
+	for i, rule := range f.Rules {
+		if rule.Attribute == "" {
+			return fmt.Errorf("Rule %d has no attribute", i)
+		}
+		if _, ok := f.Variants[rule.Variant]; rule.Variant != "" && !ok {
+			return fmt.Errorf("Rule %d picks unknown variant %s", i, rule.Variant)
+		}
+	}
+	return nil
+}
+
+func (r Rule) matches(attributes Attributes) bool {
+	value, ok := attributes[r.Attribute]
+	if !ok {
+		return false
+	}
+	for _, candidate := range r.Values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// bucket hashes subject into [0, size), salted so that flags don't share buckets
+func bucket(salt, subject string, size uint64) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(salt))
+	hash.Write([]byte{0})
+	hash.Write([]byte(subject))
+	return hash.Sum64() % size
+}
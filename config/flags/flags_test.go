@@ -0,0 +1,71 @@
+package flags
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetIgnoresCase(t *testing.T) {
+	// Flags read from config files come with their names lowercased
+	set, err := NewSet(Config{"newcheckout": {Enabled: true}})
+	if err != nil {
+		t.Fatalf("Failed to create set: %v", err)
+	}
+	for _, name := range []string{"newcheckout", "newCheckout", "NEWCHECKOUT"} {
+		if !set.Enabled(context.Background(), name) {
+			t.Errorf("Expected %s to be enabled", name)
+		}
+	}
+
+	// Flags built in code may be declared in any case
+	if err := set.Update(Config{"newCheckout": {Enabled: true}}); err != nil {
+		t.Fatalf("Failed to update set: %v", err)
+	}
+	if !set.Enabled(context.Background(), "newCheckout") {
+		t.Error("Expected newCheckout to be enabled")
+	}
+}
+
+func TestSetRejectsInvalidNames(t *testing.T) {
+	set, err := NewSet(Config{"beta": {Enabled: true}})
+	if err != nil {
+		t.Fatalf("Failed to create set: %v", err)
+	}
+
+	for _, config := range []Config{
+		{"checkout.v2": {Enabled: true}},
+		{"beta": {Enabled: true}, "Beta": {}},
+	} {
+		if err := set.Update(config); err == nil {
+			t.Errorf("Expected %v to be rejected", config)
+		}
+	}
+	if !set.Enabled(context.Background(), "beta") {
+		t.Error("Expected the previous flags to be kept")
+	}
+}
+
+func TestSetRollout(t *testing.T) {
+	rollout := 50.0
+	set, err := NewSet(Config{"half": {Enabled: true, Rollout: &rollout}})
+	if err != nil {
+		t.Fatalf("Failed to create set: %v", err)
+	}
+
+	var enabled int
+	for i := 0; i < 1000; i++ {
+		ctx := WithUser(context.Background(), string(rune('a'+i%26))+string(rune('a'+i/26)))
+		if set.Enabled(ctx, "half") {
+			enabled++
+		}
+		if set.Enabled(ctx, "half") != set.Enabled(ctx, "HALF") {
+			t.Fatal("Expected the same subject to get the same result")
+		}
+	}
+	if enabled < 400 || enabled > 600 {
+		t.Errorf("Expected about half of the subjects to be enabled, got %d of 1000", enabled)
+	}
+	if set.Enabled(context.Background(), "half") {
+		t.Error("Expected a rollout to be off without a subject")
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlanckProject/go-commons/config/defaults"
+	"github.com/PlanckProject/go-commons/config/hooks"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// descTagName is the struct tag describing a field in flag usages and generated docs
+const descTagName = "desc"
+
+// WithFlags binds the flags of fs named after the keys of the config, as
+// defined by RegisterFlags. Flags set on the command line take precedence
+// over the environment, the files and the defaults.
+func WithFlags(fs *pflag.FlagSet) Option {
+	return func(o *options) {
+		o.flags = fs
+	}
+}
+
+// RegisterFlags defines a flag on fs for every key of cfg, named after the
+// key, such as --logger.level or --redis.database. The default and desc tags
+// of a field provide the default value and usage of its flag. Keys that
+// already have a flag are skipped.
+func RegisterFlags(fs *pflag.FlagSet, cfg interface{}) error {
+	var err error
+	walkFields(reflect.TypeOf(cfg), "", func(key string, field reflect.StructField) {
+		if err != nil || fs.Lookup(key) != nil {
+			return
+		}
+		if flagErr := registerFlag(fs, key, field); flagErr != nil {
+			err = fmt.Errorf("Failed to define flag --%s: %w", key, flagErr)
+		}
+	})
+	return err
+}
+
+func registerFlag(fs *pflag.FlagSet, key string, field reflect.StructField) error {
+	defaultValue := field.Tag.Get(defaults.TagName)
+	usage := field.Tag.Get(descTagName)
+	if usage == "" {
+		usage = fmt.Sprintf("Sets %s", key)
+	}
+
+	fieldType := field.Type
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	var err error
+	switch {
+	case fieldType == hooks.DurationType:
+		var value time.Duration
+		if defaultValue != "" {
+			value, err = time.ParseDuration(defaultValue)
+		}
+		fs.Duration(key, value, usage)
+	case hooks.Decodes(fieldType):
+		// Decoded from the string by the hooks, such as a URL or a byte size
+		fs.String(key, defaultValue, usage)
+	case fieldType.Kind() == reflect.Bool:
+		var value bool
+		if defaultValue != "" {
+			value, err = strconv.ParseBool(defaultValue)
+		}
+		fs.Bool(key, value, usage)
+	case isIntKind(fieldType.Kind()):
+		var value int64
+		if defaultValue != "" {
+			value, err = strconv.ParseInt(defaultValue, 10, 64)
+		}
+		fs.Int64(key, value, usage)
+	case isUintKind(fieldType.Kind()):
+		var value uint64
+		if defaultValue != "" {
+			value, err = strconv.ParseUint(defaultValue, 10, 64)
+		}
+		fs.Uint64(key, value, usage)
+	case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
+		var value float64
+		if defaultValue != "" {
+			value, err = strconv.ParseFloat(defaultValue, 64)
+		}
+		fs.Float64(key, value, usage)
+	case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
+		var value []string
+		if defaultValue != "" {
+			value = strings.Split(defaultValue, ",")
+		}
+		fs.StringSlice(key, value, usage)
+	case fieldType.Kind() == reflect.Map || fieldType.Kind() == reflect.Slice:
+		// Structured values can't be expressed as a single flag
+	default:
+		fs.String(key, defaultValue, usage)
+	}
+	return err
+}
+
+// bindFlags binds the flags of fs that match a key of cfg
+func bindFlags(v *viper.Viper, fs *pflag.FlagSet, cfg interface{}) {
+	walkFields(reflect.TypeOf(cfg), "", func(key string, _ reflect.StructField) {
+		flag := fs.Lookup(key)
+		// An unset flag without a default would set an empty string, which
+		// types such as URLs and byte sizes can't be decoded from
+		if flag == nil || (!flag.Changed && flag.DefValue == "") {
+			return
+		}
+		v.BindPFlag(key, flag)
+	})
+}
@@ -0,0 +1,93 @@
+package hooks
+
+import (
+	"encoding"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Types the hooks decode from single values, for the packages walking config structs
+var (
+	DurationType        = reflect.TypeOf(time.Duration(0))
+	TimeType            = reflect.TypeOf(time.Time{})
+	URLType             = reflect.TypeOf(url.URL{})
+	IPType              = reflect.TypeOf(net.IP{})
+	IPNetType           = reflect.TypeOf(net.IPNet{})
+	RegexpType          = reflect.TypeOf(regexp.Regexp{})
+	TextUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// DecodeHook returns the hooks config values are decoded with. Strings are
+// decoded into time.Duration ("30s"), time.Time (RFC 3339), url.URL,
+// net.IP, net.IPNet ("10.0.0.0/8"), regexp.Regexp, lists split on commas
+// and any type implementing encoding.TextUnmarshaler, such as types.ByteSize
+// ("100MiB"). Pointers to these types are decoded as well.
+func DecodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+		mapstructure.StringToIPHookFunc(),
+		mapstructure.StringToIPNetHookFunc(),
+		StringToURLHookFunc(),
+		StringToRegexpHookFunc(),
+		TextUnmarshalerHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+}
+
+// StringToURLHookFunc returns a hook parsing strings into url.URL
+func StringToURLHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != URLType {
+			return data, nil
+		}
+		return url.Parse(data.(string))
+	}
+}
+
+// StringToRegexpHookFunc returns a hook compiling strings into regexp.Regexp
+func StringToRegexpHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != RegexpType {
+			return data, nil
+		}
+		return regexp.Compile(data.(string))
+	}
+}
+
+// TextUnmarshalerHookFunc returns a hook decoding strings into the types
+// implementing encoding.TextUnmarshaler through their UnmarshalText
+func TextUnmarshalerHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || !reflect.PtrTo(t).Implements(TextUnmarshalerType) {
+			return data, nil
+		}
+
+		value := reflect.New(t)
+		if err := value.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(data.(string))); err != nil {
+			return nil, fmt.Errorf("Invalid %s %q: %v", t, data, err)
+		}
+		return value.Elem().Interface(), nil
+	}
+}
+
+// Decodes tells whether DecodeHook decodes a string into t, or into what t
+// points to. Such types are set from a single value even when they're
+// structs or slices.
+func Decodes(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case DurationType, TimeType, URLType, IPType, IPNetType, RegexpType:
+		return true
+	}
+	return reflect.PtrTo(t).Implements(TextUnmarshalerType)
+}
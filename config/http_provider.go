@@ -0,0 +1,167 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// defaultPollInterval is how often remote providers are polled for changes
+const defaultPollInterval = 30 * time.Second
+
+var contentTypeFormats = map[string]string{
+	"application/json":       "json",
+	"application/yaml":       "yaml",
+	"application/x-yaml":     "yaml",
+	"text/yaml":              "yaml",
+	"text/x-yaml":            "yaml",
+	"application/toml":       "toml",
+	"text/x-java-properties": "properties",
+}
+
+// HTTPProvider reads settings from an HTTP endpoint. Responses are cached
+// by their ETag, so that polling an unchanged endpoint is cheap.
+type HTTPProvider struct {
+	// URL of the endpoint
+	URL string
+	// Format of the response, detected from its Content-Type or from the
+	// extension of URL when empty
+	Format string
+	// Interval between two polls of Watch, 30s when zero
+	Interval time.Duration
+	// Client sends the requests, http.DefaultClient when nil
+	Client *http.Client
+
+	mu       sync.Mutex
+	etag     string
+	content  []byte
+	settings map[string]interface{}
+}
+
+// NewHTTPProvider returns a Provider reading the endpoint at url
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{URL: url}
+}
+
+func (p *HTTPProvider) Name() string {
+	return p.URL
+}
+
+func (p *HTTPProvider) Read() (map[string]interface{}, error) {
+	_, err := p.fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.settings, nil
+}
+
+// Watch polls the endpoint and calls onChange when its content changes
+func (p *HTTPProvider) Watch(ctx context.Context, onChange func(), onError func(error)) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	go p.poll(ctx, interval, onChange, onError)
+	return nil
+}
+
+func (p *HTTPProvider) poll(ctx context.Context, interval time.Duration, onChange func(), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := p.fetch(ctx)
+			if err != nil {
+				onError(err)
+				continue
+			}
+			if changed {
+				onChange()
+			}
+		}
+	}
+}
+
+// fetch requests the endpoint and tells whether its content changed since the last fetch
+func (p *HTTPProvider) fetch(ctx context.Context) (bool, error) {
+	request, err := http.NewRequest(http.MethodGet, p.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	request = request.WithContext(ctx)
+
+	p.mu.Lock()
+	if p.etag != "" {
+		request.Header.Set("If-None-Match", p.etag)
+	}
+	p.mu.Unlock()
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return false, fmt.Errorf("Failed to fetch config %s: %w", p.URL, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("Failed to fetch config %s: %s", p.URL, response.Status)
+	}
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return false, fmt.Errorf("Failed to fetch config %s: %w", p.URL, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.settings != nil && string(content) == string(p.content) {
+		p.etag = response.Header.Get("ETag")
+		return false, nil
+	}
+
+	settings, err := parseSettings(p.URL, p.format(response), content)
+	if err != nil {
+		return false, err
+	}
+
+	p.etag = response.Header.Get("ETag")
+	p.content = content
+	p.settings = settings
+	return true, nil
+}
+
+func (p *HTTPProvider) format(response *http.Response) string {
+	if p.Format != "" {
+		return p.Format
+	}
+	if mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type")); err == nil {
+		if format, ok := contentTypeFormats[mediaType]; ok {
+			return format
+		}
+	}
+	if u, err := url.Parse(p.URL); err == nil {
+		return configType(u.Path)
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type configServer struct {
+	mu          sync.Mutex
+	content     string
+	etag        string
+	contentType string
+	notModified int32
+}
+
+func (s *configServer) set(content, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content, s.etag = content, etag
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.etag != "" && r.Header.Get("If-None-Match") == s.etag {
+		atomic.AddInt32(&s.notModified, 1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Header().Set("Content-Type", s.contentType)
+	w.Write([]byte(s.content))
+}
+
+func TestHTTPProviderRead(t *testing.T) {
+	server := &configServer{content: `{"server": {"port": 8080}}`, etag: `"v1"`, contentType: "application/json"}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	provider := NewHTTPProvider(httpServer.URL + "/config")
+	settings, err := provider.Read()
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if port := settings["server"].(map[string]interface{})["port"]; port != float64(8080) {
+		t.Errorf("Expected port 8080, got %v", port)
+	}
+
+	// The second read is answered from the cache thanks to the ETag
+	if _, err := provider.Read(); err != nil {
+		t.Fatalf("Failed to read config again: %v", err)
+	}
+	if notModified := atomic.LoadInt32(&server.notModified); notModified != 1 {
+		t.Errorf("Expected 1 not modified response, got %d", notModified)
+	}
+}
+
+func TestHTTPProviderFormatFromURL(t *testing.T) {
+	server := &configServer{content: "server:\n  port: 9090\n"}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	settings, err := NewHTTPProvider(httpServer.URL + "/config.yaml").Read()
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if port := settings["server"].(map[string]interface{})["port"]; port != 9090 {
+		t.Errorf("Expected port 9090, got %v", port)
+	}
+}
+
+func TestHTTPProviderError(t *testing.T) {
+	httpServer := httptest.NewServer(http.NotFoundHandler())
+	defer httpServer.Close()
+
+	if _, err := NewHTTPProvider(httpServer.URL + "/config.json").Read(); err == nil {
+		t.Error("Expected an error for a missing endpoint")
+	}
+}
+
+func TestHTTPProviderWatch(t *testing.T) {
+	server := &configServer{content: `{"level": "info"}`, etag: `"v1"`, contentType: "application/json"}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	provider := NewHTTPProvider(httpServer.URL)
+	provider.Interval = 10 * time.Millisecond
+	if _, err := provider.Read(); err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 10)
+	if err := provider.Watch(ctx, func() { changes <- struct{}{} }, func(err error) { t.Errorf("Unexpected watch error: %v", err) }); err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+
+	select {
+	case <-changes:
+		t.Fatal("Expected no change while the content is the same")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	server.set(`{"level": "debug"}`, `"v2"`)
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a change once the content changed")
+	}
+
+	settings, err := provider.Read()
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if settings["level"] != "debug" {
+		t.Errorf("Expected level debug, got %v", settings["level"])
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/PlanckProject/go-commons/config/defaults"
+	"github.com/PlanckProject/go-commons/config/hooks"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// Loader reads a base config file and its overlays into a struct and keeps
+// track of the source every key was read from.
+type Loader struct {
+	providers []Provider
+	options   *options
+
+	mu       sync.RWMutex
+	origins  map[string]string
+	settings map[string]interface{}
+	secrets  map[string]bool
+	sources  map[string]string
+}
+
+// NewLoader returns a Loader for the config file at path, its overlays and
+// providers. path may be empty when every layer comes from a provider.
+func NewLoader(path string, opts ...Option) *Loader {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	files := []string{path}
+	if o.profile != "" && path != "" {
+		files = append(files, profilePath(path, o.profile))
+	}
+
+	var providers []Provider
+	for _, file := range append(files, o.overlays...) {
+		if file != "" {
+			providers = append(providers, &fileProvider{fsys: o.fsys, path: file, format: o.format})
+		}
+	}
+
+	return &Loader{
+		providers: append(providers, o.providers...),
+		options:   o,
+		origins:   make(map[string]string),
+	}
+}
+
+// Load reads every layer in order and decodes the merged result into cfg
+func (l *Loader) Load(cfg interface{}) error {
+	settings := make(map[string]interface{})
+	origins := make(map[string]string)
+
+	for _, provider := range l.providers {
+		providerSettings, err := provider.Read()
+		if err != nil {
+			return err
+		}
+		mergeSettings(settings, providerSettings, origins, provider.Name(), "")
+	}
+
+	v := viper.New()
+	setDefaults(v, cfg)
+	if err := v.MergeConfigMap(settings); err != nil {
+		return err
+	}
+
+	if l.options.envEnabled {
+		bindEnv(v, l.options.envPrefix, cfg)
+	}
+	if l.options.flags != nil {
+		bindFlags(v, l.options.flags, cfg)
+	}
+
+	effective := v.AllSettings()
+	secrets := make(map[string]bool)
+	secretKeys(cfg, secrets)
+	if err := l.options.resolveSecrets(effective, secrets, ""); err != nil {
+		return err
+	}
+
+	metadata := &mapstructure.Metadata{}
+	if err := decode(effective, cfg, metadata); err != nil {
+		return redactError(err, secrets)
+	}
+	if err := l.options.handleUnknownKeys(cfg, metadata.Unused, origins); err != nil {
+		return err
+	}
+	if err := Validate(cfg); err != nil {
+		return redactError(err, secrets)
+	}
+
+	l.mu.Lock()
+	l.origins = origins
+	l.settings = effective
+	l.secrets = secrets
+	l.sources = l.sourcesOf(effective, origins)
+	l.mu.Unlock()
+	return nil
+}
+
+// Origin returns the file the value of key was last read from
+func (l *Loader) Origin(key string) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	origin, ok := l.origins[strings.ToLower(key)]
+	return origin, ok
+}
+
+// Origins returns the file every key was last read from
+func (l *Loader) Origins() map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	origins := make(map[string]string, len(l.origins))
+	for key, origin := range l.origins {
+		origins[key] = origin
+	}
+	return origins
+}
+
+// setDefaults registers the default tags of cfg as the lowest layer,
+// below the files and the environment
+func setDefaults(v *viper.Viper, cfg interface{}) {
+	walkFields(reflect.TypeOf(cfg), "", func(key string, field reflect.StructField) {
+		if defaultValue, ok := field.Tag.Lookup(defaults.TagName); ok && defaultValue != "" {
+			v.SetDefault(key, defaultValue)
+		}
+	})
+}
+
+// mergeSettings merges a copy of src into dst. Maps are merged deeply, every
+// other value replaces the one in dst. The origin of each replaced leaf is set to source.
+func mergeSettings(dst, src map[string]interface{}, origins map[string]string, source, prefix string) {
+	for key, srcValue := range src {
+		fullKey := joinKey(prefix, key)
+
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+
+		if srcIsMap && dstIsMap {
+			mergeSettings(dstMap, srcMap, origins, source, fullKey)
+			continue
+		}
+
+		deleteOrigins(origins, fullKey)
+		if srcIsMap {
+			dstMap = make(map[string]interface{}, len(srcMap))
+			dst[key] = dstMap
+			mergeSettings(dstMap, srcMap, origins, source, fullKey)
+			continue
+		}
+
+		dst[key] = copyValue(srcValue)
+		origins[fullKey] = source
+	}
+}
+
+// copyValue copies lists, so that resolving secrets never modifies what a provider returned
+func copyValue(value interface{}) interface{} {
+	list, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	copied := make([]interface{}, len(list))
+	for i, item := range list {
+		copied[i] = copyValue(item)
+	}
+	return copied
+}
+
+func deleteOrigins(origins map[string]string, key string) {
+	for originKey := range origins {
+		if originKey == key || strings.HasPrefix(originKey, key+".") {
+			delete(origins, originKey)
+		}
+	}
+}
+
+func decode(settings map[string]interface{}, cfg interface{}, metadata *mapstructure.Metadata) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           cfg,
+		Metadata:         metadata,
+		WeaklyTypedInput: true,
+		DecodeHook:       hooks.DecodeHook(),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = decoder.Decode(settings)
+	if err != nil {
+		return newDecodeError(err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+type (
+	// Provider supplies one layer of configuration. Layers are merged in the
+	// order they are given to the Loader.
+	Provider interface {
+		// Name identifies the provider in errors and as the source of its settings
+		Name() string
+		// Read returns the settings of the provider nested by key
+		Read() (map[string]interface{}, error)
+	}
+
+	// WatchableProvider is a Provider that can tell when its settings change
+	WatchableProvider interface {
+		Provider
+		// Watch returns once changes are picked up, then calls onChange from
+		// the background whenever the settings may have changed, until ctx is
+		// done. An error returned means nothing is watched, errors met while
+		// watching are passed to onError.
+		Watch(ctx context.Context, onChange func(), onError func(error)) error
+	}
+
+	fileProvider struct {
+		fsys   fs.FS
+		path   string
+		format string
+	}
+
+	readerProvider struct {
+		reader io.Reader
+		format string
+
+		once    sync.Once
+		content []byte
+		err     error
+	}
+)
+
+// WithFormat sets the format of the config file and its overlays, such as
+// yaml, json, toml, hcl, dotenv or properties, instead of detecting it from
+// their extension. It's needed for files without an extension.
+func WithFormat(format string) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithFS reads the config file and its overlays from fsys, such as an
+// embed.FS bundled into the binary, instead of the local file system
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// WithReader adds a layer read from r in the given format after the config files.
+// With an empty path, Load and Parse read the config from r alone.
+func WithReader(r io.Reader, format string) Option {
+	return WithProvider(NewReaderProvider(r, format))
+}
+
+// WithProvider adds a layer read from p after the config files
+func WithProvider(p Provider) Option {
+	return func(o *options) {
+		o.providers = append(o.providers, p)
+	}
+}
+
+// NewFileProvider returns a Provider reading the config file at path.
+// Its format is given by the extension of the file.
+func NewFileProvider(path string) WatchableProvider {
+	return &fileProvider{path: path}
+}
+
+// NewFSProvider returns a Provider reading the config file at path in fsys.
+// Its format is given by the extension of the file.
+func NewFSProvider(fsys fs.FS, path string) Provider {
+	return &fileProvider{fsys: fsys, path: path}
+}
+
+// NewReaderProvider returns a Provider reading the content of r in the given format.
+// r is read once, on the first Read.
+func NewReaderProvider(r io.Reader, format string) Provider {
+	return &readerProvider{reader: r, format: format}
+}
+
+func (p *fileProvider) Name() string {
+	return p.path
+}
+
+func (p *fileProvider) Read() (map[string]interface{}, error) {
+	var content []byte
+	var err error
+	if p.fsys != nil {
+		content, err = fs.ReadFile(p.fsys, p.path)
+	} else {
+		content, err = ioutil.ReadFile(p.path)
+	}
+
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &FileNotFoundError{Path: p.path}
+		}
+		return nil, fmt.Errorf("Failed to read config %s: %w", p.path, err)
+	}
+
+	format := p.format
+	if format == "" {
+		format = configType(p.path)
+	}
+	return parseSettings(p.path, format, content)
+}
+
+// Watch watches the directory of the file instead of the file itself, so
+// that files replaced by editors or by symlink swaps on mounted volumes are
+// still picked up
+func (p *fileProvider) Watch(ctx context.Context, onChange func(), onError func(error)) error {
+	if p.fsys != nil {
+		// Files bundled into the binary never change
+		return nil
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Clean(p.path)
+	if err := fsWatcher.Add(filepath.Dir(file)); err != nil {
+		fsWatcher.Close()
+		return err
+	}
+
+	resolved, _ := filepath.EvalSymlinks(file)
+
+	go p.watch(ctx, fsWatcher, file, resolved, onChange, onError)
+	return nil
+}
+
+func (p *fileProvider) watch(ctx context.Context, fsWatcher *fsnotify.Watcher, file, resolved string, onChange func(), onError func(error)) {
+	defer fsWatcher.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return
+			}
+
+			changed := filepath.Clean(event.Name) == file &&
+				event.Op&(fsnotify.Write|fsnotify.Create) != 0
+
+			// Mounted volumes swap a symlink without touching the file itself
+			if current, _ := filepath.EvalSymlinks(file); current != resolved {
+				resolved = current
+				changed = true
+			}
+
+			if changed {
+				onChange()
+			}
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			onError(err)
+		}
+	}
+}
+
+func (p *readerProvider) Name() string {
+	return "reader"
+}
+
+func (p *readerProvider) Read() (map[string]interface{}, error) {
+	p.once.Do(func() {
+		p.content, p.err = ioutil.ReadAll(p.reader)
+	})
+	if p.err != nil {
+		return nil, fmt.Errorf("Failed to read config: %w", p.err)
+	}
+	return parseSettings(p.Name(), p.format, p.content)
+}
+
+// parseSettings parses content in the given format, name identifies it in errors
+func parseSettings(name, format string, content []byte) (map[string]interface{}, error) {
+	if !isSupportedType(format) {
+		return nil, &ParseError{Path: name, Err: viper.UnsupportedConfigError(format)}
+	}
+
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, newParseError(name, err)
+	}
+	return v.AllSettings(), nil
+}
+
+// configType returns the format of a config file from its last extension, so
+// that app.config.yaml is yaml and .env is dotenv. It's empty without one.
+func configType(path string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+func isSupportedType(format string) bool {
+	for _, ext := range viper.SupportedExts {
+		if ext == strings.ToLower(format) {
+			return true
+		}
+	}
+	return false
+}
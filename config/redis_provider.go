@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	commonsredis "github.com/PlanckProject/go-commons/cache/redis"
+	"github.com/go-redis/redis"
+)
+
+// RedisProvider reads settings stored as a document in a single redis key
+type RedisProvider struct {
+	// Client connects to the redis server
+	Client *redis.Client
+	// Key holding the document
+	Key string
+	// Format of the document, such as yaml or json
+	Format string
+	// Interval between two polls of Watch, 30s when zero
+	Interval time.Duration
+
+	mu      sync.Mutex
+	content string
+	missing bool
+}
+
+// NewRedisProvider connects to the redis server described by config and
+// returns a Provider reading the document stored in key
+func NewRedisProvider(config *commonsredis.Config, key, format string) (*RedisProvider, error) {
+	client, err := commonsredis.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisProvider{Client: client, Key: key, Format: format}, nil
+}
+
+func (p *RedisProvider) Name() string {
+	options := p.Client.Options()
+	return fmt.Sprintf("redis://%s/%d/%s", options.Addr, options.DB, p.Key)
+}
+
+func (p *RedisProvider) Read() (map[string]interface{}, error) {
+	content, err := p.Client.Get(p.Key).Result()
+	if err == redis.Nil {
+		return nil, &FileNotFoundError{Path: p.Name()}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config %s: %w", p.Name(), err)
+	}
+
+	p.mu.Lock()
+	p.content, p.missing = content, false
+	p.mu.Unlock()
+	return parseSettings(p.Name(), p.Format, []byte(content))
+}
+
+// Watch listens to the keyspace notifications of the key, which requires
+// notify-keyspace-events to include K and $ on the server, and polls the
+// key on every Interval for servers where they are disabled. A key deleted
+// is reported once to onError as a *FileNotFoundError, the settings read
+// before are kept until it's set again.
+func (p *RedisProvider) Watch(ctx context.Context, onChange func(), onError func(error)) error {
+	channel := fmt.Sprintf("__keyspace@%d__:%s", p.Client.Options().DB, p.Key)
+	pubsub := p.Client.Subscribe(channel)
+	// Notifications are only sent once the subscription is confirmed
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("Failed to subscribe to %s: %w", channel, err)
+	}
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	go p.listen(ctx, pubsub, channel, interval, onChange, onError)
+	return nil
+}
+
+func (p *RedisProvider) listen(ctx context.Context, pubsub *redis.PubSub, channel string, interval time.Duration, onChange func(), onError func(error)) {
+	defer pubsub.Close()
+	notifications := pubsub.Channel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-notifications:
+			if !ok {
+				onError(fmt.Errorf("Subscription to %s closed", channel))
+				return
+			}
+			p.poll(onChange, onError)
+		case <-ticker.C:
+			p.poll(onChange, onError)
+		}
+	}
+}
+
+// poll reads the key and calls onChange when its content changed
+func (p *RedisProvider) poll(onChange func(), onError func(error)) {
+	content, err := p.Client.Get(p.Key).Result()
+	if err != nil && err != redis.Nil {
+		onError(fmt.Errorf("Failed to read config %s: %w", p.Name(), err))
+		return
+	}
+
+	p.mu.Lock()
+	missing := err == redis.Nil
+	reportMissing := missing && !p.missing
+	changed := !missing && content != p.content
+	p.missing = missing
+	p.mu.Unlock()
+
+	if reportMissing {
+		onError(&FileNotFoundError{Path: p.Name()})
+	}
+	if changed {
+		onChange()
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+func newTestRedisProvider(t *testing.T) (*miniredis.Miniredis, *RedisProvider) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, &RedisProvider{Client: client, Key: "config", Format: "json", Interval: 10 * time.Millisecond}
+}
+
+func TestRedisProviderRead(t *testing.T) {
+	server, provider := newTestRedisProvider(t)
+
+	var notFound *FileNotFoundError
+	if _, err := provider.Read(); !errors.As(err, &notFound) {
+		t.Fatalf("Expected a *FileNotFoundError for a missing key, got %v", err)
+	}
+
+	server.Set("config", `{"cache": {"ttl": "5m"}}`)
+	settings, err := provider.Read()
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if ttl := settings["cache"].(map[string]interface{})["ttl"]; ttl != "5m" {
+		t.Errorf("Expected ttl 5m, got %v", ttl)
+	}
+}
+
+func TestRedisProviderWatch(t *testing.T) {
+	server, provider := newTestRedisProvider(t)
+	server.Set("config", `{"level": "info"}`)
+	if _, err := provider.Read(); err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 10)
+	errs := make(chan error, 10)
+	if err := provider.Watch(ctx, func() { changes <- struct{}{} }, func(err error) { errs <- err }); err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+
+	server.Set("config", `{"level": "debug"}`)
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a change once the key changed")
+	}
+	if _, err := provider.Read(); err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+
+	// A deleted key is reported once, without triggering reloads
+	server.Del("config")
+	select {
+	case err := <-errs:
+		var notFound *FileNotFoundError
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected a *FileNotFoundError, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the deleted key to be reported")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if len(changes) > 0 || len(errs) > 0 {
+		t.Errorf("Expected no change nor error after the key was reported missing, got %d changes and %d errors", len(changes), len(errs))
+	}
+
+	server.Set("config", `{"level": "warn"}`)
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a change once the key was set again")
+	}
+}
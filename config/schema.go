@@ -0,0 +1,308 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlanckProject/go-commons/config/defaults"
+	"github.com/PlanckProject/go-commons/config/hooks"
+	"gopkg.in/yaml.v2"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema returns a JSON Schema describing the keys cfg accepts, built from
+// its mapstructure, default, desc and validate tags. Unknown keys are not
+// allowed, so that typos are caught before the config is rolled out.
+func Schema(cfg interface{}) ([]byte, error) {
+	schema := structSchema(indirectType(reflect.TypeOf(cfg)))
+	schema["$schema"] = jsonSchemaDraft
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// SampleYAML returns a YAML document setting every key of cfg to its default,
+// each preceded by a comment with its description, type and rules
+func SampleYAML(cfg interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := writeSampleYAML(buffer, indirectType(reflect.TypeOf(cfg)), 0); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	eachField(t, func(name string, field reflect.StructField) {
+		properties[name] = fieldSchema(field)
+		_, hasDefault := field.Tag.Lookup(defaults.TagName)
+		if !hasDefault && hasRule(field, "required") {
+			required = append(required, name)
+		}
+	})
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func fieldSchema(field reflect.StructField) map[string]interface{} {
+	schema := typeSchema(field.Type)
+
+	if description := field.Tag.Get(descTagName); description != "" {
+		schema["description"] = description
+	}
+	if defaultValue, ok := field.Tag.Lookup(defaults.TagName); ok && defaultValue != "" {
+		schema["default"] = typedDefault(field.Type, defaultValue)
+	}
+
+	for _, rule := range parseRules(field.Tag.Get(validateTagName)) {
+		switch rule.name {
+		case "oneof":
+			var enum []interface{}
+			for _, option := range strings.Fields(rule.param) {
+				enum = append(enum, typedDefault(field.Type, option))
+			}
+			if schema["type"] == "array" {
+				schema["items"].(map[string]interface{})["enum"] = enum
+			} else {
+				// Validate accepts the zero value of an omitempty field
+				if hasRule(field, "omitempty") {
+					enum = append(enum, reflect.Zero(indirectType(field.Type)).Interface())
+				}
+				schema["enum"] = enum
+			}
+		case "url":
+			schema["format"] = "uri"
+		case "hostport":
+			schema["pattern"] = `^.+:[0-9]{1,5}$`
+		case "min", "max":
+			bound, err := strconv.ParseFloat(rule.param, 64)
+			if err != nil {
+				continue
+			}
+			switch schema["type"] {
+			case "string":
+				schema[rule.name+"Length"] = bound
+			case "array":
+				schema[rule.name+"Items"] = bound
+			case "object":
+				schema[rule.name+"Properties"] = bound
+			default:
+				schema[map[string]string{"min": "minimum", "max": "maximum"}[rule.name]] = bound
+			}
+		}
+	}
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	t = indirectType(t)
+
+	switch {
+	case t == hooks.DurationType:
+		return map[string]interface{}{"type": "string", "pattern": `^([0-9.]+(ns|us|µs|ms|s|m|h))+$`}
+	case t == hooks.TimeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == hooks.URLType:
+		return map[string]interface{}{"type": "string", "format": "uri"}
+	case t == hooks.RegexpType:
+		return map[string]interface{}{"type": "string", "format": "regex"}
+	case t == hooks.IPNetType:
+		return map[string]interface{}{"type": "string", "pattern": `^[0-9a-fA-F.:]+/[0-9]{1,3}$`}
+	case reflect.PtrTo(t).Implements(hooks.TextUnmarshalerType):
+		// Numbers such as types.ByteSize are still accepted without a unit
+		if isIntKind(t.Kind()) || isUintKind(t.Kind()) {
+			return map[string]interface{}{"type": []string{"integer", "string"}}
+		}
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	}
+	if isIntKind(t.Kind()) {
+		return map[string]interface{}{"type": "integer"}
+	}
+	if isUintKind(t.Kind()) {
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	}
+	return map[string]interface{}{}
+}
+
+// typedDefault converts a tag value into the JSON type of the field
+func typedDefault(t reflect.Type, value string) interface{} {
+	t = indirectType(t)
+	if t == hooks.DurationType {
+		return value
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	case reflect.Float32, reflect.Float64:
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	case reflect.Slice, reflect.Array:
+		var items []interface{}
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, typedDefault(t.Elem(), item))
+		}
+		return items
+	}
+	if isIntKind(t.Kind()) || isUintKind(t.Kind()) {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
+
+func writeSampleYAML(buffer *bytes.Buffer, t reflect.Type, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	var err error
+
+	eachField(t, func(name string, field reflect.StructField) {
+		if err != nil {
+			return
+		}
+
+		for _, line := range sampleComment(field) {
+			fmt.Fprintf(buffer, "%s# %s\n", indent, line)
+		}
+
+		if isNestedStruct(field.Type) {
+			fmt.Fprintf(buffer, "%s%s:\n", indent, name)
+			err = writeSampleYAML(buffer, indirectType(field.Type), depth+1)
+			return
+		}
+
+		// Left unset, since its zero value would fail validation
+		if zeroRejected(field) {
+			fmt.Fprintf(buffer, "%s# %s:\n", indent, name)
+			return
+		}
+
+		// Marshalling through a flow field keeps lists and maps on the key's line
+		var value []byte
+		value, err = yaml.Marshal(struct {
+			Value interface{} `yaml:"value,flow"`
+		}{sampleValue(field)})
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(buffer, "%s%s: %s", indent, name, bytes.TrimPrefix(value, []byte("value: ")))
+	})
+	return err
+}
+
+func sampleComment(field reflect.StructField) []string {
+	var lines []string
+	if description := field.Tag.Get(descTagName); description != "" {
+		lines = append(lines, description)
+	}
+	if isNestedStruct(field.Type) {
+		return lines
+	}
+
+	line := fmt.Sprintf("Type: %s", typeName(field.Type))
+	if rules := field.Tag.Get(validateTagName); rules != "" {
+		line += fmt.Sprintf(", rules: %s", rules)
+	}
+	return append(lines, line)
+}
+
+func sampleValue(field reflect.StructField) interface{} {
+	if defaultValue, ok := field.Tag.Lookup(defaults.TagName); ok && defaultValue != "" {
+		return typedDefault(field.Type, defaultValue)
+	}
+
+	t := indirectType(field.Type)
+	switch {
+	case t == hooks.DurationType:
+		return time.Duration(0).String()
+	case hooks.Decodes(t) && (t.Kind() == reflect.Struct || t.Kind() == reflect.Slice):
+		// URLs, IPs, networks and regexps have no meaningful zero value
+		return ""
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return []interface{}{}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{}
+	}
+	return reflect.Zero(t).Interface()
+}
+
+// zeroRejected tells whether the zero value of an optional field without a
+// default breaks its rules, such as oneof without omitempty
+func zeroRejected(field reflect.StructField) bool {
+	if defaultValue, ok := field.Tag.Lookup(defaults.TagName); ok && defaultValue != "" {
+		return false
+	}
+	if hasRule(field, "required") || hasRule(field, "omitempty") {
+		return false
+	}
+
+	zero := reflect.Zero(field.Type)
+	for _, rule := range parseRules(field.Tag.Get(validateTagName)) {
+		if rule.name != "required_without" && rule.check(reflect.Value{}, zero) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(t reflect.Type) string {
+	t = indirectType(t)
+	switch {
+	case t == hooks.DurationType:
+		return "duration"
+	case hooks.Decodes(t):
+		// Decoded from a single value whatever its kind, such as net.IP
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return "list of " + typeName(t.Elem())
+	case t.Kind() == reflect.Map:
+		return "map of " + typeName(t.Elem())
+	}
+	switch schemaType := typeSchema(t)["type"].(type) {
+	case []string:
+		return strings.Join(schemaType, " or ")
+	case string:
+		return schemaType
+	}
+	return "any"
+}
+
+func hasRule(field reflect.StructField, name string) bool {
+	for _, rule := range parseRules(field.Tag.Get(validateTagName)) {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
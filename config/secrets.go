@@ -0,0 +1,196 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// Redacted replaces secret values whenever a config is dumped or reported in an error
+const Redacted = "[REDACTED]"
+
+var (
+	secretReferencePattern = regexp.MustCompile(`\$\{([a-zA-Z][a-zA-Z0-9_-]*):([^}]*)\}`)
+
+	secretResolversMu sync.RWMutex
+	secretResolvers   = map[string]SecretResolver{
+		"env":    SecretResolverFunc(resolveEnv),
+		"file":   SecretResolverFunc(resolveFile),
+		"base64": SecretResolverFunc(resolveBase64),
+	}
+)
+
+type (
+	// SecretResolver resolves the reference of a ${scheme:reference} config value
+	SecretResolver interface {
+		Resolve(reference string) (string, error)
+	}
+
+	// SecretResolverFunc lets an ordinary function be used as a SecretResolver
+	SecretResolverFunc func(reference string) (string, error)
+)
+
+func (f SecretResolverFunc) Resolve(reference string) (string, error) {
+	return f(reference)
+}
+
+// RegisterSecretResolver makes ${scheme:...} references resolvable by every Loader.
+// The env, file and base64 schemes are registered by default.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	secretResolversMu.Lock()
+	secretResolvers[scheme] = resolver
+	secretResolversMu.Unlock()
+}
+
+// WithSecretResolver makes ${scheme:...} references resolvable by this Loader only
+func WithSecretResolver(scheme string, resolver SecretResolver) Option {
+	return func(o *options) {
+		if o.secretResolvers == nil {
+			o.secretResolvers = make(map[string]SecretResolver)
+		}
+		o.secretResolvers[scheme] = resolver
+	}
+}
+
+func (o *options) secretResolver(scheme string) (SecretResolver, bool) {
+	if resolver, ok := o.secretResolvers[scheme]; ok {
+		return resolver, true
+	}
+	secretResolversMu.RLock()
+	defer secretResolversMu.RUnlock()
+	resolver, ok := secretResolvers[scheme]
+	return resolver, ok
+}
+
+// resolveSecrets replaces the secret references found in the string values
+// of settings and records the keys they were found in. References with an
+// unknown scheme are left untouched.
+func (o *options) resolveSecrets(settings map[string]interface{}, secrets map[string]bool, prefix string) error {
+	var errs error
+	for key, value := range settings {
+		fullKey := joinKey(prefix, key)
+
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			errs = multierr.Append(errs, o.resolveSecrets(typedValue, secrets, fullKey))
+		case string:
+			resolved, found, err := o.resolveString(typedValue)
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("Failed to resolve secret of %s: %w", fullKey, err))
+			} else if found {
+				settings[key] = resolved
+				secrets[fullKey] = true
+			}
+		case []interface{}:
+			for i, item := range typedValue {
+				text, ok := item.(string)
+				if !ok {
+					continue
+				}
+				resolved, found, err := o.resolveString(text)
+				if err != nil {
+					errs = multierr.Append(errs, fmt.Errorf("Failed to resolve secret of %s: %w", fullKey, err))
+				} else if found {
+					typedValue[i] = resolved
+					secrets[fullKey] = true
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func (o *options) resolveString(value string) (string, bool, error) {
+	found := false
+	var resolveErr error
+
+	resolved := secretReferencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		match := secretReferencePattern.FindStringSubmatch(reference)
+		resolver, ok := o.secretResolver(match[1])
+		if !ok {
+			return reference
+		}
+
+		secret, err := resolver.Resolve(match[2])
+		if err != nil {
+			resolveErr = multierr.Append(resolveErr, err)
+			return reference
+		}
+		found = true
+		return secret
+	})
+	return resolved, found, resolveErr
+}
+
+// redactError hides the values of secret keys that decode and validation errors quote
+func redactError(err error, secrets map[string]bool) error {
+	var result error
+	for _, e := range multierr.Errors(err) {
+		switch typedErr := e.(type) {
+		case *DecodeError:
+			if secrets[typedErr.Key] {
+				e = &DecodeError{Key: typedErr.Key, Message: fmt.Sprintf("'%s' has an invalid secret value", typedErr.Key)}
+			}
+		case *ValidationError:
+			redactedErr := &ValidationError{}
+			for _, fieldErr := range typedErr.Errors {
+				if secrets[fieldErr.Field] {
+					fieldErr = &FieldError{Field: fieldErr.Field, Rule: fieldErr.Rule,
+						Message: fmt.Sprintf("fails rule '%s'", fieldErr.Rule)}
+				}
+				redactedErr.Errors = append(redactedErr.Errors, fieldErr)
+			}
+			e = redactedErr
+		}
+		result = multierr.Append(result, e)
+	}
+	return result
+}
+
+// redactSettings returns a copy of settings with the values of secret keys replaced
+func redactSettings(settings map[string]interface{}, secrets map[string]bool, prefix string) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		fullKey := joinKey(prefix, key)
+		if secrets[fullKey] {
+			redacted[key] = Redacted
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			redacted[key] = redactSettings(nested, secrets, fullKey)
+			continue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("Environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+func resolveFile(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+func resolveBase64(encoded string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
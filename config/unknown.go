@@ -0,0 +1,144 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/PlanckProject/go-commons/logger"
+	"go.uber.org/multierr"
+)
+
+// UnknownKeys tells how keys matching no field of the config are handled
+type UnknownKeys int
+
+const (
+	// IgnoreUnknownKeys drops unknown keys silently, the default
+	IgnoreUnknownKeys UnknownKeys = iota
+	// WarnUnknownKeys logs a warning for every unknown key
+	WarnUnknownKeys
+	// RejectUnknownKeys fails the load with an UnknownKeyError for every unknown key.
+	// Use multierr.Errors to get the individual errors back.
+	RejectUnknownKeys
+)
+
+// WithUnknownKeys sets how keys matching no field of the config, such as
+// typos, are handled. They're ignored by default.
+func WithUnknownKeys(mode UnknownKeys) Option {
+	return func(o *options) {
+		o.unknownKeys = mode
+	}
+}
+
+// unknownKeyErrors returns an UnknownKeyError for every key in unused, with
+// the provider it was read from and the closest key of cfg
+func unknownKeyErrors(cfg interface{}, unused []string, origins map[string]string) []*UnknownKeyError {
+	sort.Strings(unused)
+	known := knownKeys(cfg)
+
+	var errs []*UnknownKeyError
+	for _, key := range unused {
+		errs = append(errs, &UnknownKeyError{
+			Key:        key,
+			Source:     originOf(origins, key),
+			Suggestion: closestKey(key, known),
+		})
+	}
+	return errs
+}
+
+// handleUnknownKeys reports the unused keys as mode requires
+func (o *options) handleUnknownKeys(cfg interface{}, unused []string, origins map[string]string) error {
+	if o.unknownKeys == IgnoreUnknownKeys || len(unused) == 0 {
+		return nil
+	}
+
+	var result error
+	for _, unknownErr := range unknownKeyErrors(cfg, unused, origins) {
+		if o.unknownKeys == WarnUnknownKeys {
+			logger.WithField("key", unknownErr.Key).Warn(unknownErr.Error())
+			continue
+		}
+		result = multierr.Append(result, unknownErr)
+	}
+	return result
+}
+
+// knownKeys returns every key of cfg, nested structs included
+func knownKeys(cfg interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	walkFields(reflect.TypeOf(cfg), "", func(key string, _ reflect.StructField) {
+		for _, candidate := range keyAndParents(key) {
+			if !seen[candidate] {
+				seen[candidate] = true
+				keys = append(keys, candidate)
+			}
+		}
+	})
+	return keys
+}
+
+// originOf returns the provider key was read from. Unknown sections are
+// reported as a whole, so their origin is the one of their first leaf.
+func originOf(origins map[string]string, key string) string {
+	if origin, ok := origins[key]; ok {
+		return origin
+	}
+
+	var leaves []string
+	for leaf := range origins {
+		if strings.HasPrefix(leaf, key+".") {
+			leaves = append(leaves, leaf)
+		}
+	}
+	if len(leaves) == 0 {
+		return ""
+	}
+	sort.Strings(leaves)
+	return origins[leaves[0]]
+}
+
+// closestKey returns the known key with the smallest edit distance to key,
+// or an empty string when none is close enough to be a typo
+func closestKey(key string, known []string) string {
+	closest := ""
+	best := len(key)/3 + 1
+	for _, candidate := range known {
+		if distance := levenshtein(key, candidate); distance < best {
+			closest, best = candidate, distance
+		}
+	}
+	return closest
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minOf(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minOf(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
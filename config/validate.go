@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlanckProject/go-commons/config/hooks"
+)
+
+const validateTagName = "validate"
+
+type (
+	// FieldError is a single validation rule a config field failed
+	FieldError struct {
+		Field   string
+		Rule    string
+		Message string
+	}
+
+	// ValidationError holds every FieldError found in a config
+	ValidationError struct {
+		Errors []*FieldError
+	}
+
+	validationRule struct {
+		name  string
+		param string
+	}
+)
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Error()
+	}
+	return fmt.Sprintf("Invalid config: %s", strings.Join(messages, "; "))
+}
+
+// Validate checks cfg against the rules in its validate struct tags and
+// returns a *ValidationError listing every field that fails. Load calls it
+// after decoding. The supported rules, separated by commas, are:
+//
+//	required                a non-zero value, or a non-empty slice or map
+//	required_without=Field  required unless the sibling Field is set
+//	min=N, max=N            bounds of numbers, durations and lengths
+//	oneof=a b c             one of the space separated values
+//	url                     an absolute URL
+//	hostport                a host:port pair
+//	omitempty               skips the other rules when the value is zero
+//
+// min, max and oneof are checked on zero numbers and strings too, so that
+// an optional field needs omitempty, and required alone decides whether a
+// value may be absent. The other rules are only checked on non-zero values.
+// On slices, oneof, url and hostport are checked for every element.
+func Validate(cfg interface{}) error {
+	validationErr := &ValidationError{}
+	validateStruct(reflect.ValueOf(cfg), "", validationErr)
+	if len(validationErr.Errors) > 0 {
+		return validationErr
+	}
+	return nil
+}
+
+func validateStruct(value reflect.Value, prefix string, validationErr *ValidationError) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, squash := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		key := prefix
+		if !squash {
+			key = joinKey(prefix, name)
+		}
+
+		rules := parseRules(field.Tag.Get(validateTagName))
+		if hasValidationRule(rules, "omitempty") && isZero(value.Field(i)) {
+			rules = nil
+		}
+		for _, rule := range rules {
+			if message := rule.check(value, value.Field(i)); message != "" {
+				validationErr.Errors = append(validationErr.Errors,
+					&FieldError{Field: key, Rule: rule.name, Message: message})
+			}
+		}
+
+		if isNestedStruct(field.Type) {
+			validateStruct(value.Field(i), key, validationErr)
+		}
+	}
+}
+
+func parseRules(tag string) []validationRule {
+	if tag == "" {
+		return nil
+	}
+
+	var rules []validationRule
+	for _, token := range strings.Split(tag, ",") {
+		tokens := strings.SplitN(strings.TrimSpace(token), "=", 2)
+		rule := validationRule{name: tokens[0]}
+		if len(tokens) == 2 {
+			rule.param = tokens[1]
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r validationRule) check(parent, value reflect.Value) string {
+	switch r.name {
+	case "required":
+		if isZero(value) {
+			return "is required"
+		}
+		return ""
+	case "required_without":
+		sibling := parent.FieldByName(r.param)
+		if isZero(value) && (!sibling.IsValid() || isZero(sibling)) {
+			return fmt.Sprintf("is required when %s is not set", r.param)
+		}
+		return ""
+	case "omitempty":
+		return ""
+	}
+
+	// A zero number or string is a value of its own, anything else zero is absent
+	checkedWhenZero := (r.name == "min" || r.name == "max" || r.name == "oneof") && isScalarKind(value.Kind())
+	if isZero(value) && !checkedWhenZero {
+		return ""
+	}
+
+	switch r.name {
+	case "min", "max":
+		return checkBound(r.name, r.param, value)
+	case "oneof", "url", "hostport":
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+			for i := 0; i < value.Len(); i++ {
+				if message := checkFormat(r.name, r.param, value.Index(i)); message != "" {
+					return message
+				}
+			}
+			return ""
+		}
+		return checkFormat(r.name, r.param, value)
+	}
+	return fmt.Sprintf("has unknown validation rule '%s'", r.name)
+}
+
+func checkBound(name, param string, value reflect.Value) string {
+	var actual, bound float64
+	var err error
+
+	switch {
+	case value.Type() == hooks.DurationType:
+		var duration time.Duration
+		duration, err = time.ParseDuration(param)
+		actual, bound = float64(value.Int()), float64(duration)
+	case isLengthKind(value.Kind()):
+		actual = float64(value.Len())
+		bound, err = strconv.ParseFloat(param, 64)
+	case isIntKind(value.Kind()):
+		actual = float64(value.Int())
+		bound, err = strconv.ParseFloat(param, 64)
+	case isUintKind(value.Kind()):
+		actual = float64(value.Uint())
+		bound, err = strconv.ParseFloat(param, 64)
+	case value.Kind() == reflect.Float32 || value.Kind() == reflect.Float64:
+		actual = value.Float()
+		bound, err = strconv.ParseFloat(param, 64)
+	default:
+		return fmt.Sprintf("does not support rule '%s'", name)
+	}
+	if err != nil {
+		return fmt.Sprintf("has invalid %s bound '%s'", name, param)
+	}
+
+	subject := "must be"
+	if isLengthKind(value.Kind()) {
+		subject = "length must be"
+	}
+	if name == "min" && actual < bound {
+		return fmt.Sprintf("%s at least %s", subject, param)
+	}
+	if name == "max" && actual > bound {
+		return fmt.Sprintf("%s at most %s", subject, param)
+	}
+	return ""
+}
+
+func checkFormat(name, param string, value reflect.Value) string {
+	text := fmt.Sprint(value.Interface())
+
+	switch name {
+	case "oneof":
+		for _, option := range strings.Fields(param) {
+			if text == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s], got '%s'", param, text)
+	case "url":
+		u, err := url.Parse(text)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Sprintf("must be an absolute URL, got '%s'", text)
+		}
+	case "hostport":
+		host, port, err := net.SplitHostPort(text)
+		if err != nil || host == "" {
+			return fmt.Sprintf("must be host:port, got '%s'", text)
+		}
+		if portNumber, err := strconv.ParseUint(port, 10, 16); err != nil || portNumber == 0 {
+			return fmt.Sprintf("has invalid port in '%s'", text)
+		}
+	}
+	return ""
+}
+
+func isZero(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func hasValidationRule(rules []validationRule, name string) bool {
+	for _, rule := range rules {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Float32 || kind == reflect.Float64 ||
+		isIntKind(kind) || isUintKind(kind)
+}
+
+func isLengthKind(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uintptr
+}
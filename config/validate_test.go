@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateZeroValues(t *testing.T) {
+	type config struct {
+		Count    int    `mapstructure:"count" validate:"min=1"`
+		Name     string `mapstructure:"name" validate:"min=3"`
+		Mode     string `mapstructure:"mode" validate:"oneof=fast slow"`
+		Optional string `mapstructure:"optional" validate:"omitempty,oneof=fast slow"`
+		Address  string `mapstructure:"address" validate:"hostport"`
+	}
+
+	err := Validate(&config{})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a *ValidationError, got %v", err)
+	}
+
+	failed := make(map[string]string)
+	for _, fieldErr := range validationErr.Errors {
+		failed[fieldErr.Field] = fieldErr.Rule
+	}
+	expected := map[string]string{"count": "min", "name": "min", "mode": "oneof"}
+	if len(failed) != len(expected) {
+		t.Fatalf("Expected failures %v, got %v", expected, failed)
+	}
+	for field, rule := range expected {
+		if failed[field] != rule {
+			t.Errorf("Expected %s to fail %s, got %v", field, rule, failed)
+		}
+	}
+}
+
+func TestValidateOmitEmpty(t *testing.T) {
+	type config struct {
+		Optional string `mapstructure:"optional" validate:"omitempty,oneof=fast slow"`
+	}
+
+	if err := Validate(&config{}); err != nil {
+		t.Errorf("Expected an empty optional value to pass, got %v", err)
+	}
+	if err := Validate(&config{Optional: "medium"}); err == nil {
+		t.Errorf("Expected a value out of oneof to fail")
+	}
+}
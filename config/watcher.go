@@ -0,0 +1,193 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/PlanckProject/go-commons/logger"
+)
+
+// reloadDelay groups the bursts of changes editors and volume mounts make for a single edit
+const reloadDelay = 100 * time.Millisecond
+
+// Watcher reloads a configuration whenever one of its files changes and
+// notifies the subscribers with the old and the new value.
+type Watcher struct {
+	loader     *Loader
+	configType reflect.Type
+	cancel     context.CancelFunc
+	reloadMu   sync.Mutex
+	timerMu    sync.Mutex
+	timer      *time.Timer
+
+	mu            sync.RWMutex
+	current       interface{}
+	subscribers   []reflect.Value
+	errorHandlers []func(error)
+}
+
+// Watch loads the config file at path into cfg like Load does, then watches
+// the file, its overlays and every WatchableProvider. Every change is decoded into a fresh value of the
+// same type as cfg; cfg itself is never modified after the first load.
+// A failed reload keeps the last good config and is reported to the error handlers.
+// The providers are watched by the time Watch returns, so that no change
+// made afterwards is missed.
+func Watch(cfg interface{}, path string, opts ...Option) (*Watcher, error) {
+	configType := reflect.TypeOf(cfg)
+	if configType == nil || configType.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("Config must be a pointer, got %T", cfg)
+	}
+
+	loader := NewLoader(path, opts...)
+	if err := loader.Load(cfg); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		loader:     loader,
+		configType: configType.Elem(),
+		cancel:     cancel,
+		current:    cfg,
+	}
+
+	for _, provider := range loader.providers {
+		if watchable, ok := provider.(WatchableProvider); ok {
+			if err := watchable.Watch(ctx, w.scheduleReload, w.reportError); err != nil {
+				cancel()
+				return nil, fmt.Errorf("Failed to watch %s: %w", watchable.Name(), err)
+			}
+		}
+	}
+
+	// Changes made between the first load and the watches are picked up by
+	// loading again, into a fresh value so that no stale key is left in cfg
+	next := reflect.New(w.configType)
+	if err := loader.Load(next.Interface()); err != nil {
+		cancel()
+		return nil, err
+	}
+	reflect.ValueOf(cfg).Elem().Set(next.Elem())
+	return w, nil
+}
+
+// Loader returns the loader used for every reload
+func (w *Watcher) Loader() *Loader {
+	return w.loader
+}
+
+// Current returns the last config that was loaded successfully, a pointer
+// of the same type as the one passed to Watch
+func (w *Watcher) Current() interface{} {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe registers fn to be called after every successful reload.
+// fn must be a func(old, new *T) where *T is the type passed to Watch.
+// The level of the logger can be kept in sync like this:
+//
+//	watcher.Subscribe(func(old, new *AppConfig) {
+//		if old.Logger.Level != new.Logger.Level {
+//			logger.SetLevel(new.Logger.Level)
+//		}
+//	})
+func (w *Watcher) Subscribe(fn interface{}) error {
+	fnValue := reflect.ValueOf(fn)
+	fnType := reflect.TypeOf(fn)
+	configType := reflect.PtrTo(w.configType)
+
+	if fnType == nil ||
+		fnType.Kind() != reflect.Func ||
+		fnType.NumIn() != 2 ||
+		fnType.NumOut() != 0 ||
+		fnType.In(0) != configType ||
+		fnType.In(1) != configType {
+		return fmt.Errorf("Subscriber must be a func(old, new %s), got %s", configType, fnType)
+	}
+
+	w.mu.Lock()
+	w.subscribers = append(w.subscribers, fnValue)
+	w.mu.Unlock()
+	return nil
+}
+
+// OnError registers fn to be called whenever a reload or a subscriber fails.
+// Errors are logged if no handler is registered.
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	w.errorHandlers = append(w.errorHandlers, fn)
+	w.mu.Unlock()
+}
+
+// Reload loads the configuration again and notifies the subscribers if it succeeds
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	next := reflect.New(w.configType)
+	if err := w.loader.Load(next.Interface()); err != nil {
+		w.reportError(err)
+		return err
+	}
+
+	w.mu.Lock()
+	previous := w.current
+	w.current = next.Interface()
+	subscribers := append([]reflect.Value(nil), w.subscribers...)
+	w.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		w.notify(subscriber, reflect.ValueOf(previous), next)
+	}
+	return nil
+}
+
+// Close stops watching the providers
+func (w *Watcher) Close() error {
+	w.cancel()
+
+	w.timerMu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timerMu.Unlock()
+	return nil
+}
+
+func (w *Watcher) scheduleReload() {
+	w.timerMu.Lock()
+	defer w.timerMu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(reloadDelay, func() { w.Reload() })
+}
+
+func (w *Watcher) notify(subscriber, previous, next reflect.Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.reportError(fmt.Errorf("Config subscriber panicked: %v", r))
+		}
+	}()
+	subscriber.Call([]reflect.Value{previous, next})
+}
+
+func (w *Watcher) reportError(err error) {
+	w.mu.RLock()
+	errorHandlers := w.errorHandlers
+	w.mu.RUnlock()
+
+	if len(errorHandlers) == 0 {
+		logger.WithField("error", err).Error("Failed to reload config")
+		return
+	}
+	for _, errorHandler := range errorHandlers {
+		errorHandler(err)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type watchedConfig struct {
+	Level string `mapstructure:"level"`
+}
+
+func TestWatchPicksUpChangeRightAfterReturning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeConfig(t, path, "level: info\n")
+
+	cfg := &watchedConfig{}
+	watcher, err := Watch(cfg, path)
+	if err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+	defer watcher.Close()
+
+	changes := make(chan string, 1)
+	if err := watcher.Subscribe(func(old, new *watchedConfig) { changes <- new.Level }); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	writeConfig(t, path, "level: debug\n")
+
+	select {
+	case level := <-changes:
+		if level != "debug" {
+			t.Errorf("Reloaded level %s, want debug", level)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Change made right after Watch returned was missed")
+	}
+}
+
+func TestWatchKeepsCfgCurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeConfig(t, path, "level: info\n")
+
+	cfg := &watchedConfig{}
+	watcher, err := Watch(cfg, path)
+	if err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+	defer watcher.Close()
+
+	if current := watcher.Current().(*watchedConfig); current != cfg || current.Level != "info" {
+		t.Errorf("Current is %+v, want cfg with level info", current)
+	}
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -1,13 +1,13 @@
 package mongo
 
 type Config struct {
-	Hosts            []string `mapstructure:"hosts"`
-	ReplicaSet       string   `mapstructure:"replica_set"`
-	Username         string   `mapstructure:"username"`
-	Password         string   `mapstructure:"password"`
-	SSL              bool     `mapstructure:"ssl"`
-	RetryWrites      bool     `mapstructure:"retry_writes"`
-	ConnectionString string   `mapstructure:"connection_string"`
-	Database         string   `mapstructure:"database"`
-	Collection       string   `mapstructure:"collection"`
+	Hosts            []string `mapstructure:"hosts" validate:"required_without=ConnectionString" desc:"Hosts of the mongo deployment"`
+	ReplicaSet       string   `mapstructure:"replica_set" desc:"Name of the replica set"`
+	Username         string   `mapstructure:"username" desc:"User to authenticate as"`
+	Password         string   `mapstructure:"password" secret:"true" desc:"Password of the user"`
+	SSL              bool     `mapstructure:"ssl" desc:"Connects over TLS"`
+	RetryWrites      bool     `mapstructure:"retry_writes" desc:"Retries failed writes once"`
+	ConnectionString string   `mapstructure:"connection_string" validate:"url" secret:"true" desc:"Connection string, used instead of the other connection settings"`
+	Database         string   `mapstructure:"database" desc:"Name of the database"`
+	Collection       string   `mapstructure:"collection" desc:"Name of the collection"`
 }
@@ -1,15 +1,37 @@
 module github.com/PlanckProject/go-commons
 
-go 1.13
+go 1.21
 
 require (
+	github.com/alicebob/miniredis/v2 v2.31.0
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/go-redis/redis v6.15.6+incompatible
-	github.com/go-redis/redis/v7 v7.0.0-beta.4 // indirect
-	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
-	github.com/natefinch/lumberjack v2.0.0+incompatible
+	github.com/mitchellh/mapstructure v1.1.2
 	github.com/prometheus/common v0.4.0
+	github.com/rs/zerolog v1.33.0
 	github.com/sirupsen/logrus v1.4.2
+	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.6.1
-	go.uber.org/multierr v1.1.0
-	golang.org/x/sys v0.0.0-20191224085550-c709ea063b76 // indirect
+	go.uber.org/multierr v1.10.0
+	go.uber.org/zap v1.27.0
+	gopkg.in/yaml.v2 v2.2.4
+)
+
+require (
+	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
+	github.com/go-redis/redis/v7 v7.0.0-beta.4 // indirect
+	github.com/hashicorp/hcl v1.0.0 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/magiconair/properties v1.8.1 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	github.com/pelletier/go-toml v1.2.0 // indirect
+	github.com/spf13/afero v1.1.2 // indirect
+	github.com/spf13/cast v1.3.0 // indirect
+	github.com/spf13/jwalterweatherman v1.0.0 // indirect
+	github.com/subosito/gotenv v1.2.0 // indirect
+	github.com/yuin/gopher-lua v1.1.0 // indirect
+	golang.org/x/sys v0.12.0 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	gopkg.in/ini.v1 v1.51.0 // indirect
 )
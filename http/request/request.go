@@ -10,17 +10,30 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/PlanckProject/go-commons/config/defaults"
 	"github.com/PlanckProject/go-commons/constants"
 	"github.com/PlanckProject/go-commons/logger"
 	"go.uber.org/multierr"
 )
 
+// Config holds the settings every new request starts with. Retries is a
+// pointer, so that 0 disables retries while nil falls back to the default.
+type Config struct {
+	Timeout time.Duration `mapstructure:"timeout" default:"30s" desc:"Timeout of every attempt of a request"`
+	Retries *uint8        `mapstructure:"retries" default:"3" desc:"Attempts made after a failed one, none when 0"`
+}
+
+var defaultConfig = newDefaultConfig()
+
+// requestLogger logs under http.request, so that its level can be set apart
+var requestLogger = logger.Named("http.request")
+
 type httpRequest struct {
 	request *http.Request
 	timeout time.Duration
 	payload []byte
 	header  map[string]string
-	retries uint8
+	retries int
 }
 
 type byteReaderCloser struct {
@@ -29,17 +42,42 @@ type byteReaderCloser struct {
 
 func (byteReaderCloser) Close() error { return nil }
 
+func newDefaultConfig() *Config {
+	config := &Config{}
+	if err := defaults.Apply(config); err != nil {
+		panic(err.Error())
+	}
+	return config
+}
+
+// Configure sets the timeout and retries of the requests created afterwards.
+// Unset values fall back to their defaults, config itself is left as is.
+// A nil config restores the defaults.
+func Configure(config *Config) error {
+	if config == nil {
+		defaultConfig = newDefaultConfig()
+		return nil
+	}
+
+	configured := *config
+	if err := defaults.Apply(&configured); err != nil {
+		return err
+	}
+	defaultConfig = &configured
+	return nil
+}
+
 func New() *httpRequest {
 	request, err := http.NewRequest(constants.EmptyString, constants.EmptyString, nil)
 	if err != nil {
-		logger.Error("Failed to create a http request")
+		requestLogger.Error("Failed to create a http request")
 		return nil
 	}
 	return &httpRequest{
 		request: request,
 		header:  make(map[string]string),
-		retries: 4, // 1 + 3 retries. Leaving for now, will need to be properly piped into a worker with retires treated as a seperate action
-		timeout: 30 * time.Second,
+		retries: int(*defaultConfig.Retries) + 1, // Leaving for now, will need to be properly piped into a worker with retires treated as a seperate action
+		timeout: defaultConfig.Timeout,
 	}
 }
 
@@ -53,7 +91,7 @@ func (h *httpRequest) SetMethod(method string) *httpRequest {
 		method != constants.MethodPost &&
 		method != constants.MethodPut &&
 		method != constants.MethodDelete {
-		logger.Errorf("Invalid/Unsupported http method: %s", method)
+		requestLogger.Errorf("Invalid/Unsupported http method: %s", method)
 		return nil
 	}
 	h.request.Method = method
@@ -63,7 +101,7 @@ func (h *httpRequest) SetMethod(method string) *httpRequest {
 func (h *httpRequest) SetURI(uri string) *httpRequest {
 	u, err := url.Parse(uri)
 	if err != nil {
-		logger.Errorf("Invalid URL %s", uri)
+		requestLogger.Errorf("Invalid URL %s", uri)
 		return nil
 	}
 	h.request.URL = u
@@ -99,7 +137,7 @@ func (h *httpRequest) SetTimeout(timeout time.Duration) *httpRequest {
 }
 
 func (h *httpRequest) SetRetries(retries uint8) *httpRequest {
-	h.retries = retries + 1
+	h.retries = int(retries) + 1
 	return h
 }
 
@@ -128,7 +166,7 @@ func (h *httpRequest) Do() (*http.Response, error) {
 		if err != nil {
 			if urlError, ok := err.(*url.Error); ok {
 				if urlError.Timeout() {
-					logger.WithFields(getRequestFields(h.request.Method,
+					requestLogger.WithFields(getRequestFields(h.request.Method,
 						h.request.URL.RequestURI(),
 						string(h.payload),
 						h.header,
@@ -139,7 +177,7 @@ func (h *httpRequest) Do() (*http.Response, error) {
 				}
 			} else {
 				err = multierr.Append(err, fmt.Errorf("Call failed at retry number %d", h.retries-retries-1))
-				logger.WithFields(getRequestFields(h.request.Method,
+				requestLogger.WithFields(getRequestFields(h.request.Method,
 					h.request.URL.RequestURI(),
 					string(h.payload),
 					h.header,
@@ -165,12 +203,12 @@ func (h *httpRequest) Do() (*http.Response, error) {
 			nil)
 		logFieldMap["http.response.payload"] = string(responsePayload)
 		logFieldMap["http.response.code"] = response.StatusCode
-		logger.WithFields(logFieldMap).
+		requestLogger.WithFields(logFieldMap).
 			Infof("API call successful")
 		return response, nil
 	}
 
-	logger.WithFields(getRequestFields(h.request.Method,
+	requestLogger.WithFields(getRequestFields(h.request.Method,
 		h.request.URL.RequestURI(),
 		string(h.payload),
 		h.header,
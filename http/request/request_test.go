@@ -0,0 +1,38 @@
+package request
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigureKeepsConfig(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+
+	config := &Config{}
+	if err := Configure(config); err != nil {
+		t.Fatalf("Failed to configure: %v", err)
+	}
+	if config.Timeout != 0 || config.Retries != nil {
+		t.Errorf("Configure changed the config to %+v", config)
+	}
+
+	request := New()
+	if request.timeout != 30*time.Second || request.retries != 4 {
+		t.Errorf("Request has timeout %v and %d attempts, want the defaults", request.timeout, request.retries)
+	}
+}
+
+func TestConfigureNilRestoresDefaults(t *testing.T) {
+	retries := uint8(0)
+	if err := Configure(&Config{Timeout: time.Second, Retries: &retries}); err != nil {
+		t.Fatalf("Failed to configure: %v", err)
+	}
+	if err := Configure(nil); err != nil {
+		t.Fatalf("Failed to configure: %v", err)
+	}
+
+	request := New()
+	if request.timeout != 30*time.Second || request.retries != 4 {
+		t.Errorf("Request has timeout %v and %d attempts, want the defaults", request.timeout, request.retries)
+	}
+}
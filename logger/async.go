@@ -0,0 +1,253 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// BufferPolicy tells what an AsyncWriter does with entries written while its buffer is full
+type BufferPolicy string
+
+const (
+	// BlockOnFull makes writers wait for room in the buffer
+	BlockOnFull BufferPolicy = "block"
+	// DropNewest drops the entry being written
+	DropNewest BufferPolicy = "drop_newest"
+	// DropOldest drops the oldest buffered entry to make room
+	DropOldest BufferPolicy = "drop_oldest"
+)
+
+type (
+	// AsyncConfig writes entries from a background goroutine, so that a slow
+	// writer doesn't stall the goroutines logging
+	AsyncConfig struct {
+		Enabled bool         `mapstructure:"enabled" desc:"Writes entries from a background goroutine"`
+		Size    uint         `mapstructure:"size" default:"1024" validate:"min=1" desc:"Entries buffered before the policy applies"`
+		Policy  BufferPolicy `mapstructure:"policy" default:"block" validate:"oneof=block drop_newest drop_oldest" desc:"Handling of entries written to a full buffer, block, drop_newest or drop_oldest"`
+	}
+
+	// AsyncStats counts the entries of an AsyncWriter
+	AsyncStats struct {
+		// Queued is the number of entries in the buffer
+		Queued int
+		// Written is the number of entries written to the underlying writer
+		Written uint64
+		// Dropped is the number of entries dropped by the policy
+		Dropped uint64
+		// Failed is the number of entries the underlying writer failed to write
+		Failed uint64
+	}
+
+	// AsyncWriter buffers the entries written to it in a bounded ring buffer
+	// and writes them to another writer from a background goroutine
+	AsyncWriter struct {
+		writer io.Writer
+		policy BufferPolicy
+
+		mu       sync.Mutex
+		notEmpty *sync.Cond
+		notFull  *sync.Cond
+		drained  *sync.Cond
+		buffer   [][]byte
+		head     int
+		count    int
+		writing  bool
+		closed   bool
+		failing  bool
+		done     chan struct{}
+		stats    AsyncStats
+	}
+)
+
+var (
+	asyncWritersMu sync.Mutex
+	asyncWriters   = make(map[*AsyncWriter]bool)
+)
+
+// NewAsyncWriter returns an AsyncWriter buffering up to size entries for writer.
+// It must be closed to stop its goroutine.
+func NewAsyncWriter(writer io.Writer, size uint, policy BufferPolicy) (*AsyncWriter, error) {
+	switch policy {
+	case BlockOnFull, DropNewest, DropOldest:
+	case "":
+		policy = BlockOnFull
+	default:
+		return nil, fmt.Errorf("Unsupported buffer policy '%s'", policy)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("Invalid buffer size 0")
+	}
+
+	w := &AsyncWriter{
+		writer: writer,
+		policy: policy,
+		buffer: make([][]byte, size),
+		done:   make(chan struct{}),
+	}
+	w.notEmpty = sync.NewCond(&w.mu)
+	w.notFull = sync.NewCond(&w.mu)
+	w.drained = sync.NewCond(&w.mu)
+
+	asyncWritersMu.Lock()
+	asyncWriters[w] = true
+	asyncWritersMu.Unlock()
+
+	go w.run()
+	return w, nil
+}
+
+// Write queues a copy of p, every call being a single entry. It never fails
+// on a full buffer, the entries dropped by the policy are counted instead.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.count == len(w.buffer) && w.policy == BlockOnFull && !w.closed {
+		w.notFull.Wait()
+	}
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	if w.count == len(w.buffer) {
+		w.stats.Dropped++
+		if w.policy == DropNewest {
+			return len(p), nil
+		}
+		w.buffer[w.head] = nil
+		w.head = (w.head + 1) % len(w.buffer)
+		w.count--
+	}
+
+	w.buffer[(w.head+w.count)%len(w.buffer)] = entry
+	w.count++
+	w.notEmpty.Signal()
+	return len(p), nil
+}
+
+// Flush waits until every buffered entry is written, then syncs the
+// underlying writer when it's a file or anything with a Sync method
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	for w.count > 0 || w.writing {
+		w.drained.Wait()
+	}
+	w.mu.Unlock()
+
+	if syncer, ok := w.writer.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// Close flushes the buffer, stops the goroutine and closes the underlying
+// writer when it's an io.Closer. Entries written afterwards fail.
+func (w *AsyncWriter) Close() error {
+	if !w.stop() {
+		return nil
+	}
+	if closer, ok := w.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Stats returns the counters of the writer
+func (w *AsyncWriter) Stats() AsyncStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := w.stats
+	stats.Queued = w.count
+	return stats
+}
+
+// stop flushes the buffer and stops the goroutine, leaving the underlying
+// writer open. It tells whether the writer was still running.
+func (w *AsyncWriter) stop() bool {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return false
+	}
+	w.closed = true
+	w.notEmpty.Broadcast()
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+
+	asyncWritersMu.Lock()
+	delete(asyncWriters, w)
+	asyncWritersMu.Unlock()
+	return true
+}
+
+// run writes the buffered entries until the writer is closed and its buffer empty
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for {
+		for w.count == 0 && !w.closed {
+			w.notEmpty.Wait()
+		}
+		if w.count == 0 {
+			w.drained.Broadcast()
+			return
+		}
+
+		entry := w.buffer[w.head]
+		w.buffer[w.head] = nil
+		w.head = (w.head + 1) % len(w.buffer)
+		w.count--
+		w.writing = true
+		w.notFull.Signal()
+
+		w.mu.Unlock()
+		_, err := w.writer.Write(entry)
+		w.mu.Lock()
+
+		w.writing = false
+		if err != nil {
+			w.stats.Failed++
+			// Reported once until the writer recovers, Failed counts the rest
+			if !w.failing {
+				fmt.Fprintf(os.Stderr, "Failed to write to log until it recovers, %v\n", err)
+			}
+			w.failing = true
+		} else {
+			w.stats.Written++
+			w.failing = false
+		}
+		if w.count == 0 {
+			w.drained.Broadcast()
+		}
+	}
+}
+
+// Flush waits until the entries buffered by every open AsyncWriter are
+// written. It's meant for graceful shutdowns, fatal and panic entries flush
+// on their own.
+func Flush() error {
+	asyncWritersMu.Lock()
+	writers := make([]*AsyncWriter, 0, len(asyncWriters))
+	for w := range asyncWriters {
+		writers = append(writers, w)
+	}
+	asyncWritersMu.Unlock()
+
+	var err error
+	for _, w := range writers {
+		err = multierr.Append(err, w.Flush())
+	}
+	return err
+}
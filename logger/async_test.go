@@ -0,0 +1,34 @@
+package logger_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PlanckProject/go-commons/logger"
+)
+
+func TestGetAsyncStats(t *testing.T) {
+	logger.Configure(&logger.Config{Enabled: true, Async: logger.AsyncConfig{Enabled: true}}, &bytes.Buffer{})
+	t.Cleanup(func() { logger.Configure(&logger.Config{}, os.Stderr) })
+
+	for i := 0; i < 3; i++ {
+		logger.Info("entry")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for logger.GetAsyncStats().Written < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if stats := logger.GetAsyncStats(); stats.Written != 3 || stats.Dropped != 0 {
+		t.Errorf("Stats = %+v, want 3 entries written", stats)
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Failed to close the logger: %v", err)
+	}
+	if stats := logger.GetAsyncStats(); stats != (logger.AsyncStats{}) {
+		t.Errorf("Stats = %+v once closed, want none", stats)
+	}
+}
@@ -0,0 +1,97 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/PlanckProject/go-commons/logger"
+)
+
+// callers log an entry each and return the line they logged it from
+var callers = map[string]func() int{
+	"package": func() int {
+		line := currentLine() + 1
+		logger.Warn("entry")
+		return line
+	},
+	"entry": func() int {
+		line := currentLine() + 1
+		logger.WithField("key", "value").Warn("entry")
+		return line
+	},
+	"named": func() int {
+		line := currentLine() + 1
+		logger.Named("caller.test").Warn("entry")
+		return line
+	},
+	"slog handler": func() int {
+		line := currentLine() + 1
+		slog.New(logger.SlogHandler()).Warn("entry")
+		return line
+	},
+}
+
+func TestCaller(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	root := filepath.Dir(filepath.Dir(file))
+
+	modes := []struct {
+		name string
+		mode logger.CallerMode
+		trim string
+		file string
+	}{
+		{name: "short", mode: logger.CallerShort, file: "caller_test.go"},
+		{name: "long", mode: logger.CallerLong, file: file},
+		{name: "trim", mode: logger.CallerLong, trim: root, file: "logger/caller_test.go"},
+	}
+	t.Cleanup(func() { logger.Configure(&logger.Config{}, os.Stderr) })
+
+	for _, base := range []string{"logrus", "zap", "zerolog", "slog"} {
+		for _, mode := range modes {
+			for name, log := range callers {
+				t.Run(fmt.Sprintf("%s/%s/%s", base, mode.name, name), func(t *testing.T) {
+					buffer := &bytes.Buffer{}
+					logger.Configure(&logger.Config{
+						Base:         base,
+						Enabled:      true,
+						ReportCaller: true,
+						CallerMode:   mode.mode,
+						CallerTrim:   mode.trim,
+					}, buffer)
+
+					line := log()
+
+					var entry map[string]interface{}
+					if err := json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+						t.Fatalf("Failed to decode entry %q: %v", buffer.String(), err)
+					}
+					if want := fmt.Sprintf("%s:%d", mode.file, line); entry["file"] != want {
+						t.Errorf("file = %v, want %s", entry["file"], want)
+					}
+					if function, _ := entry["func"].(string); !strings.Contains(function, "logger_test.") {
+						t.Errorf("func = %v, want a function of logger_test", entry["func"])
+					}
+					if entry["level"] != "warn" {
+						t.Errorf("level = %v, want warn", entry["level"])
+					}
+					if entry["msg"] != "entry" {
+						t.Errorf("msg = %v, want entry", entry["msg"])
+					}
+				})
+			}
+		}
+	}
+}
+
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
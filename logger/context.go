@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// ContextExtractor returns the fields to add to every entry logged with a context,
+	// such as the trace and span IDs it carries
+	ContextExtractor func(ctx context.Context) Fields
+
+	entryKey struct{}
+)
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// NewContext returns a copy of ctx carrying entry, so that FromContext
+// returns it, fields included, further down the call chain
+func NewContext(ctx context.Context, entry LogEntry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext returns the entry carried by ctx, or a new entry of the package
+// logger when there's none. The entry is bound to ctx, so that the context
+// extractors run on it.
+func FromContext(ctx context.Context) LogEntry {
+	if ctx == nil {
+		return instance.NewEntry()
+	}
+	if entry, ok := ctx.Value(entryKey{}).(LogEntry); ok {
+		return entry.WithContext(ctx)
+	}
+	return instance.WithContext(ctx)
+}
+
+// ContextWithFields returns a copy of ctx carrying the entry of ctx with fields added.
+// Middlewares use it to attach request IDs, tenants or users once for every downstream call.
+func ContextWithFields(ctx context.Context, fields Fields) context.Context {
+	return NewContext(ctx, FromContext(ctx).WithFields(fields))
+}
+
+// RegisterContextExtractor adds extractor to the ones run on the context of
+// every entry. Fields set on the entry take precedence over extracted ones.
+func RegisterContextExtractor(extractor ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, extractor)
+}
+
+// ContextValueExtractor returns an extractor setting field to the value of
+// key in the context, when present
+func ContextValueExtractor(key interface{}, field string) ContextExtractor {
+	return func(ctx context.Context) Fields {
+		if value := ctx.Value(key); value != nil {
+			return Fields{field: value}
+		}
+		return nil
+	}
+}
+
+// extractContextFields adds the fields extracted from ctx that aren't set yet
+func extractContextFields(ctx context.Context, fields Fields) {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	for _, extractor := range extractors {
+		for key, value := range extractor(ctx) {
+			if _, ok := fields[key]; !ok {
+				fields[key] = value
+			}
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Keys of the message and the caller in the entries of every backend, those of logrus
+const (
+	messageKey        = logrus.FieldKeyMsg
+	callerFileKey     = logrus.FieldKeyFile
+	callerFunctionKey = logrus.FieldKeyFunc
+)
+
+// maxCallerDepth bounds the number of frames searched for the caller of the logger
+const maxCallerDepth = 32
+
+// loggerPackage prefixes the functions of this package, which are skipped
+// when looking for the caller
+var loggerPackage = reflect.TypeOf(coreEntry{}).PkgPath() + "."
+
+// exit terminates the process after a fatal entry
+var exit = os.Exit
+
+type (
+	// backend encodes entries with a logging library. Levels, hooks, fields
+	// and callers are handled before entries reach the backend, so that every
+	// library behaves the same.
+	backend interface {
+		// Write encodes entry and writes it out, whatever its level
+		Write(entry *Entry) error
+		SetWriter(writer io.Writer)
+		// SetFormat switches between the text and json encodings
+		SetFormat(format string) error
+	}
+
+	// coreLogger implements Logger on top of a backend
+	coreLogger struct {
+		coreEntry
+
+		backend backend
+		// hookLogger is the logrus logger logrus hooks get entries from
+		hookLogger   *logrus.Logger
+		level        int32
+		reportCaller int32
+		callerFormat atomic.Value
+
+		hooksMu sync.RWMutex
+		hooks   []Hook
+
+		levelsMu sync.RWMutex
+		levels   map[string]Level
+
+		samplerMu sync.RWMutex
+		sampler   *sampler
+
+		redactor atomic.Value
+	}
+
+	callerFormat struct {
+		mode CallerMode
+		trim string
+	}
+
+	coreEntry struct {
+		// logger is nil for the named entries of the package, which follow
+		// the package logger as Configure replaces it
+		logger *coreLogger
+		name   string
+		fields Fields
+		ctx    context.Context
+	}
+)
+
+func newCoreLogger(b backend) *coreLogger {
+	l := &coreLogger{backend: b, level: int32(DebugLevel), levels: make(map[string]Level)}
+	l.coreEntry.logger = l
+
+	// Other backends have no logrus logger, so hooks get one formatting as they do
+	if logrusBackend, ok := b.(*logrusBackend); ok {
+		l.hookLogger = logrusBackend.logger
+	} else {
+		l.hookLogger = logrus.New()
+		l.hookLogger.SetFormatter(logrusFormatters["json"])
+		l.hookLogger.SetReportCaller(true)
+	}
+	return l
+}
+
+// newBackend returns the backend of the given logging library, logrus by default
+func newBackend(base string) backend {
+	switch base {
+	case "zap":
+		return newZapBackend()
+	case "zerolog":
+		return newZerologBackend()
+	case "slog":
+		return newSlogBackend()
+	}
+	return newLogrusBackend()
+}
+
+func (e *coreEntry) Debug(args ...interface{}) {
+	e.log(DebugLevel, args...)
+}
+
+func (e *coreEntry) Debugf(format string, args ...interface{}) {
+	e.logf(DebugLevel, format, args...)
+}
+
+func (e *coreEntry) Debugln(args ...interface{}) {
+	e.logln(DebugLevel, args...)
+}
+
+func (e *coreEntry) Error(args ...interface{}) {
+	e.log(ErrorLevel, args...)
+}
+
+func (e *coreEntry) Errorf(format string, args ...interface{}) {
+	e.logf(ErrorLevel, format, args...)
+}
+
+func (e *coreEntry) Errorln(args ...interface{}) {
+	e.logln(ErrorLevel, args...)
+}
+
+func (e *coreEntry) Fatal(args ...interface{}) {
+	e.log(FatalLevel, args...)
+}
+
+func (e *coreEntry) Fatalf(format string, args ...interface{}) {
+	e.logf(FatalLevel, format, args...)
+}
+
+func (e *coreEntry) Fatalln(args ...interface{}) {
+	e.logln(FatalLevel, args...)
+}
+
+func (e *coreEntry) Info(args ...interface{}) {
+	e.log(InfoLevel, args...)
+}
+
+func (e *coreEntry) Infof(format string, args ...interface{}) {
+	e.logf(InfoLevel, format, args...)
+}
+
+func (e *coreEntry) Infoln(args ...interface{}) {
+	e.logln(InfoLevel, args...)
+}
+
+func (e *coreEntry) Trace(args ...interface{}) {
+	e.log(TraceLevel, args...)
+}
+
+func (e *coreEntry) Tracef(format string, args ...interface{}) {
+	e.logf(TraceLevel, format, args...)
+}
+
+func (e *coreEntry) Traceln(args ...interface{}) {
+	e.logln(TraceLevel, args...)
+}
+
+func (e *coreEntry) Panic(args ...interface{}) {
+	e.log(PanicLevel, args...)
+}
+
+func (e *coreEntry) Panicf(format string, args ...interface{}) {
+	e.logf(PanicLevel, format, args...)
+}
+
+func (e *coreEntry) Panicln(args ...interface{}) {
+	e.logln(PanicLevel, args...)
+}
+
+func (e *coreEntry) Print(args ...interface{}) {
+	e.log(InfoLevel, args...)
+}
+
+func (e *coreEntry) Printf(format string, args ...interface{}) {
+	e.logf(InfoLevel, format, args...)
+}
+
+func (e *coreEntry) Println(args ...interface{}) {
+	e.logln(InfoLevel, args...)
+}
+
+func (e *coreEntry) Warn(args ...interface{}) {
+	e.log(WarnLevel, args...)
+}
+
+func (e *coreEntry) Warnf(format string, args ...interface{}) {
+	e.logf(WarnLevel, format, args...)
+}
+
+func (e *coreEntry) Warnln(args ...interface{}) {
+	e.logln(WarnLevel, args...)
+}
+
+func (e *coreEntry) WithField(key string, value interface{}) LogEntry {
+	return e.WithFields(Fields{key: value})
+}
+
+func (e *coreEntry) WithFields(fields Fields) LogEntry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	for key, value := range e.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &coreEntry{logger: e.logger, name: e.name, fields: merged, ctx: e.ctx}
+}
+
+func (e *coreEntry) WithContext(ctx context.Context) LogEntry {
+	return &coreEntry{logger: e.logger, name: e.name, fields: e.fields, ctx: ctx}
+}
+
+func (e *coreEntry) log(level Level, args ...interface{}) {
+	if e.core().enabled(e.name, level) {
+		e.write(level, fmt.Sprint(args...))
+	}
+}
+
+func (e *coreEntry) logf(level Level, format string, args ...interface{}) {
+	if e.core().enabled(e.name, level) {
+		e.write(level, fmt.Sprintf(format, args...))
+	}
+}
+
+func (e *coreEntry) logln(level Level, args ...interface{}) {
+	if e.core().enabled(e.name, level) {
+		e.write(level, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+	}
+}
+
+func (e *coreEntry) write(level Level, message string) {
+	core := e.core()
+	if !core.sample(e.name, level, message) {
+		return
+	}
+
+	var frame *runtime.Frame
+	if core.reportsCaller() {
+		frame = core.caller()
+	}
+	e.emit(time.Now(), level, message, frame)
+
+	switch level {
+	case FatalLevel:
+		Flush()
+		exit(1)
+	case PanicLevel:
+		Flush()
+		panic(message)
+	}
+}
+
+// emit fires the hooks on the entry and writes it through the backend.
+// Unlike write, it never exits nor panics.
+func (e *coreEntry) emit(t time.Time, level Level, message string, frame *runtime.Frame) {
+	entry := &Entry{
+		Time:    t,
+		Level:   level,
+		Message: message,
+		Fields:  make(Fields, len(e.fields)),
+		Context: e.ctx,
+		Caller:  frame,
+	}
+	for key, value := range e.fields {
+		entry.Fields[key] = value
+	}
+	if e.ctx != nil {
+		extractContextFields(e.ctx, entry.Fields)
+	}
+
+	core := e.core()
+	// Redacted before the hooks, which may ship entries elsewhere
+	if redactor, _ := core.redactor.Load().(*redactor); redactor != nil {
+		redactor.redactEntry(entry)
+	}
+	core.fireHooks(entry)
+	if err := core.backend.Write(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+}
+
+// core returns the logger the entry writes through
+func (e *coreEntry) core() *coreLogger {
+	if e.logger != nil {
+		return e.logger
+	}
+	return instance.(*coreLogger)
+}
+
+func (l *coreLogger) AddHook(hook interface{}) error {
+	neutralHook, ok := toHook(hook, l.hookLogger)
+	if !ok {
+		return fmt.Errorf("Unsupported hook type attached")
+	}
+
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+	l.hooks = append(l.hooks, neutralHook)
+	return nil
+}
+
+func (l *coreLogger) NewEntry() LogEntry {
+	return &coreEntry{logger: l}
+}
+
+func (l *coreLogger) SetWriter(writer io.Writer) {
+	l.backend.SetWriter(writer)
+}
+
+func (l *coreLogger) SetReportCaller(reportCaller bool) {
+	var value int32
+	if reportCaller {
+		value = 1
+	}
+	atomic.StoreInt32(&l.reportCaller, value)
+}
+
+func (l *coreLogger) SetCallerFormat(mode CallerMode, trim string) error {
+	if mode != CallerShort && mode != CallerLong {
+		return fmt.Errorf("Unsupported caller mode '%s'", mode)
+	}
+	l.callerFormat.Store(callerFormat{mode: mode, trim: trim})
+	return nil
+}
+
+func (l *coreLogger) Named(name string) LogEntry {
+	return &coreEntry{logger: l, name: name, fields: Fields{NameField: name}}
+}
+
+func (l *coreLogger) SetSampling(config *SamplingConfig) error {
+	var next *sampler
+	if config.enabled() {
+		var err error
+		if next, err = newSampler(*config); err != nil {
+			return err
+		}
+		go next.summarize(l)
+	}
+
+	l.samplerMu.Lock()
+	previous := l.sampler
+	l.sampler = next
+	l.samplerMu.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+	return nil
+}
+
+func (l *coreLogger) SetRedaction(config *RedactionConfig) error {
+	var next *redactor
+	if config != nil && !config.Disabled {
+		var err error
+		if next, err = newRedactor(*config); err != nil {
+			return err
+		}
+	}
+	l.redactor.Store(next)
+	return nil
+}
+
+func (l *coreLogger) SetLevel(level string) {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		panic(err.Error())
+	}
+	atomic.StoreInt32(&l.level, int32(parsed))
+}
+
+func (l *coreLogger) GetLevel() Level {
+	return Level(atomic.LoadInt32(&l.level))
+}
+
+func (l *coreLogger) SetNamedLevel(name, level string) error {
+	l.levelsMu.Lock()
+	defer l.levelsMu.Unlock()
+
+	if level == "" {
+		delete(l.levels, name)
+		return nil
+	}
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.levels[name] = parsed
+	return nil
+}
+
+func (l *coreLogger) NamedLevels() map[string]Level {
+	l.levelsMu.RLock()
+	defer l.levelsMu.RUnlock()
+
+	levels := make(map[string]Level, len(l.levels))
+	for name, level := range l.levels {
+		levels[name] = level
+	}
+	return levels
+}
+
+func (l *coreLogger) SetFormatter(formatter string) {
+	if err := l.backend.SetFormat(formatter); err != nil {
+		panic(err.Error())
+	}
+	if _, ok := l.backend.(*logrusBackend); !ok {
+		l.hookLogger.SetFormatter(logrusFormatters[formatter])
+	}
+}
+
+// sample tells whether the entry passes sampling and rate limiting
+func (l *coreLogger) sample(name string, level Level, message string) bool {
+	l.samplerMu.RLock()
+	defer l.samplerMu.RUnlock()
+	return l.sampler == nil || l.sampler.allow(name, level, message)
+}
+
+// enabled tells whether entries of the logger name are written at level
+func (l *coreLogger) enabled(name string, level Level) bool {
+	return level >= l.levelOf(name)
+}
+
+// levelOf returns the level of the closest named logger, so that http.request.retry
+// falls back on http.request, then on http, then on the level of the logger
+func (l *coreLogger) levelOf(name string) Level {
+	if name != "" {
+		l.levelsMu.RLock()
+		defer l.levelsMu.RUnlock()
+
+		for len(l.levels) > 0 {
+			if level, ok := l.levels[name]; ok {
+				return level
+			}
+			index := strings.LastIndexByte(name, '.')
+			if index < 0 {
+				break
+			}
+			name = name[:index]
+		}
+	}
+	return l.GetLevel()
+}
+
+func (l *coreLogger) reportsCaller() bool {
+	return atomic.LoadInt32(&l.reportCaller) == 1
+}
+
+func (l *coreLogger) fireHooks(entry *Entry) {
+	l.hooksMu.RLock()
+	defer l.hooksMu.RUnlock()
+
+	for _, hook := range l.hooks {
+		for _, level := range hook.Levels() {
+			if level != entry.Level {
+				continue
+			}
+			if err := hook.Fire(entry); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+			}
+			break
+		}
+	}
+}
+
+// caller returns the first frame outside of this package, formatted as
+// set by SetCallerFormat
+func (l *coreLogger) caller() *runtime.Frame {
+	pcs := make([]uintptr, maxCallerDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, loggerPackage) && frame.File != "<autogenerated>" {
+			return l.formatCaller(frame)
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// formatCaller shortens or trims the file of frame as set by SetCallerFormat
+func (l *coreLogger) formatCaller(frame runtime.Frame) *runtime.Frame {
+	callerFormat, _ := l.callerFormat.Load().(callerFormat)
+	if callerFormat.mode == CallerShort {
+		frame.File = filepath.Base(frame.File)
+		frame.Function = frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+	} else if callerFormat.trim != "" && strings.HasPrefix(frame.File, callerFormat.trim) {
+		frame.File = strings.TrimLeft(strings.TrimPrefix(frame.File, callerFormat.trim), "/")
+	}
+	return &frame
+}
+
+// unsupportedFormatError is returned by backends for formats other than text and json
+func unsupportedFormatError(format string) error {
+	return fmt.Errorf("Unsupported formatter '%s'", format)
+}
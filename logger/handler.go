@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"runtime"
+)
+
+// slogHandler is a slog.Handler writing through a Logger
+type slogHandler struct {
+	logger Logger
+	fields Fields
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler writing through logger, so that
+// libraries logging with slog share its writer, format, level and hooks.
+// Attributes become fields, nested in groups as "group.key".
+// Records never exit nor panic, even at the fatal and panic levels.
+func NewSlogHandler(logger Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+// SlogHandler returns a slog.Handler writing through the package logger,
+// as configured at the time of every record
+func SlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+// RedirectStdLogs sends the records of the default slog logger and the
+// output of the standard log package through the package logger
+func RedirectStdLogs() {
+	slog.SetDefault(slog.New(SlogHandler()))
+}
+
+// StdLogger returns a standard library logger writing through the package
+// logger at level, such as for http.Server.ErrorLog
+func StdLogger(level Level) *log.Logger {
+	return slog.NewLogLogger(SlogHandler(), slogLevels[level])
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if core, ok := h.target().(*coreLogger); ok {
+		return core.enabled("", fromSlogLevel(level))
+	}
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make(Fields, len(h.fields)+record.NumAttrs())
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addSlogAttr(fields, h.prefix, attr)
+		return true
+	})
+
+	level := fromSlogLevel(record.Level)
+	logger := h.target()
+
+	if core, ok := logger.(*coreLogger); ok {
+		// Sampled as the entries of the logger, which emit would skip
+		if !core.enabled("", level) || !core.sample("", level, record.Message) {
+			return nil
+		}
+		var frame *runtime.Frame
+		if core.reportsCaller() && record.PC != 0 {
+			recordFrame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+			frame = core.formatCaller(recordFrame)
+		}
+		entry := &coreEntry{logger: core, fields: fields, ctx: ctx}
+		entry.emit(record.Time, level, record.Message, frame)
+		return nil
+	}
+
+	entry := logger.WithFields(fields).WithContext(ctx)
+	switch level {
+	case TraceLevel:
+		entry.Trace(record.Message)
+	case DebugLevel:
+		entry.Debug(record.Message)
+	case InfoLevel:
+		entry.Info(record.Message)
+	case WarnLevel:
+		entry.Warn(record.Message)
+	default:
+		entry.Error(record.Message)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Fields, len(h.fields)+len(attrs))
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	for _, attr := range attrs {
+		addSlogAttr(fields, h.prefix, attr)
+	}
+	return &slogHandler{logger: h.logger, fields: fields, prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+func (h *slogHandler) target() Logger {
+	if h.logger != nil {
+		return h.logger
+	}
+	return instance
+}
+
+// addSlogAttr adds attr to fields, flattening groups into dotted keys
+func addSlogAttr(fields Fields, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			addSlogAttr(fields, groupPrefix, groupAttr)
+		}
+		return
+	}
+	fields[prefix+attr.Key] = attr.Value.Any()
+}
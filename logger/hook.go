@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// Entry is a single log record as it's handed to hooks and backends
+	Entry struct {
+		Time    time.Time
+		Level   Level
+		Message string
+		// Fields may be modified by hooks
+		Fields Fields
+		// Context is the one given to WithContext, if any
+		Context context.Context
+		// Caller is set when the logger reports callers
+		Caller *runtime.Frame
+	}
+
+	// Hook is called for every entry of one of its levels before the entry
+	// is written, whatever the backend of the logger. Hooks may add fields.
+	Hook interface {
+		Levels() []Level
+		Fire(*Entry) error
+	}
+
+	// logrusHook runs a logrus.Hook on any backend. Entries are handed over
+	// with the logrus logger of the logger the hook was added to.
+	logrusHook struct {
+		hook   logrus.Hook
+		logger *logrus.Logger
+	}
+)
+
+// toHook returns the Hook for hook, which is either a Hook or a logrus.Hook
+func toHook(hook interface{}, logger *logrus.Logger) (Hook, bool) {
+	switch typedHook := hook.(type) {
+	case Hook:
+		return typedHook, true
+	case logrus.Hook:
+		return &logrusHook{hook: typedHook, logger: logger}, true
+	}
+	return nil, false
+}
+
+func (h *logrusHook) Levels() []Level {
+	var levels []Level
+	for _, level := range h.hook.Levels() {
+		levels = append(levels, fromLogrusLevel(level))
+	}
+	return levels
+}
+
+func (h *logrusHook) Fire(entry *Entry) error {
+	logrusEntry := toLogrusEntry(h.logger, entry)
+	if err := h.hook.Fire(logrusEntry); err != nil {
+		return err
+	}
+	entry.Message = logrusEntry.Message
+	entry.Fields = Fields(logrusEntry.Data)
+	return nil
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is the severity of an entry. Entries below the level of a logger are dropped.
+type Level int8
+
+const (
+	TraceLevel Level = iota - 2
+	DebugLevel
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	// FatalLevel entries exit the process once written
+	FatalLevel
+	// PanicLevel entries panic once written
+	PanicLevel
+)
+
+// AllLevels lists every level from the least to the most severe
+var AllLevels = []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel}
+
+var levelNames = map[Level]string{
+	TraceLevel: "trace",
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+	FatalLevel: "fatal",
+	PanicLevel: "panic",
+}
+
+// ParseLevel returns the level named name, case insensitively.
+// warning is accepted for warn.
+func ParseLevel(name string) (Level, error) {
+	lowered := strings.ToLower(strings.TrimSpace(name))
+	if lowered == "warning" {
+		return WarnLevel, nil
+	}
+	for level, levelName := range levelNames {
+		if levelName == lowered {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("Unsupported level '%s'", name)
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int8(l))
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
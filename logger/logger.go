@@ -2,14 +2,17 @@ package logger
 
 import (
 	"context"
-	"fmt"
 	"io"
 
-	"github.com/sirupsen/logrus"
+	"github.com/PlanckProject/go-commons/config/defaults"
+	"go.uber.org/multierr"
 )
 
 var instance Logger
 
+// asyncWriter buffers the writer given to Configure when Async is enabled
+var asyncWriter *AsyncWriter
+
 type (
 	LogEntry interface {
 		Debug(...interface{})
@@ -47,54 +50,174 @@ type (
 
 		AddHook(interface{}) error
 		NewEntry() LogEntry
+		// Named returns an entry of the logger name, written at the level set
+		// for name by SetNamedLevel
+		Named(string) LogEntry
 		SetWriter(io.Writer)
 		SetReportCaller(bool)
+		SetCallerFormat(CallerMode, string) error
 		SetLevel(string)
+		GetLevel() Level
+		// SetNamedLevel sets the level of the logger name and of the loggers
+		// below it, such as http.request.retry for http.request. An empty
+		// level resets name to the level of its parent.
+		SetNamedLevel(name, level string) error
+		NamedLevels() map[string]Level
+		// SetSampling drops entries as config sets, nil stops sampling
+		SetSampling(config *SamplingConfig) error
+		// SetRedaction hides sensitive values as config sets, nil writes entries as they are
+		SetRedaction(config *RedactionConfig) error
 		SetFormatter(string)
 	}
 
 	// Config represents logger configuration
 	Config struct {
-		Base         string `mapstructure:"base"`
-		Level        string `mapstructure:"level"`
-		Format       string `mapstructure:"format"`
-		Enabled      bool   `mapstructure:"enabled"`
-		MaxAge       uint   `mapstructure:"max_age"` // days
-		MaxBackups   uint   `mapstructure:"max_backups"`
-		MaxSize      uint   `mapstructure:"max_size"` // MBs
-		Compress     bool   `mapstructure:"compress"`
-		ReportCaller bool   `mapstructure:"report_caller"`
-		Filename     string `mapstructure:"filename"`
+		Base            string                 `mapstructure:"base" default:"logrus" validate:"oneof=logrus zap zerolog slog" desc:"Logging library backing the logger"`
+		Level           string                 `mapstructure:"level" default:"debug" validate:"oneof=trace debug info warn error fatal panic" desc:"Minimum level of the logged entries"`
+		Format          string                 `mapstructure:"format" default:"json" validate:"oneof=text json" desc:"Format of the logged entries"`
+		Enabled         bool                   `mapstructure:"enabled" desc:"Writes the logs to the configured writer instead of stderr"`
+		MaxAge          Days                   `mapstructure:"max_age" desc:"Days to keep rotated log files, such as 7 or 168h"`
+		MaxBackups      uint                   `mapstructure:"max_backups" desc:"Number of rotated log files to keep"`
+		MaxSize         Megabytes              `mapstructure:"max_size" desc:"Size at which the log file is rotated, in megabytes or such as 100MiB"`
+		Compress        bool                   `mapstructure:"compress" desc:"Compresses rotated log files"`
+		Rotation        string                 `mapstructure:"rotation" validate:"omitempty,oneof=hourly daily" desc:"Rotates the log file every hour or every day, on top of its size"`
+		FilenamePattern string                 `mapstructure:"filename_pattern" desc:"Name of rotated log files with the %Y %m %d %H %M %S of their period, such as app-%Y-%m-%d.log, Filename with a timestamp when empty"`
+		MaxTotalSize    Megabytes              `mapstructure:"max_total_size" desc:"Disk usage of rotated log files above which the oldest are removed, in megabytes or such as 1GiB"`
+		ReopenOnSIGHUP  bool                   `mapstructure:"reopen_on_sighup" desc:"Reopens the log file on SIGHUP, once logrotate moved it"`
+		ReportCaller    bool                   `mapstructure:"report_caller" desc:"Adds the calling file and line to every entry"`
+		CallerMode      CallerMode             `mapstructure:"caller_mode" default:"long" validate:"oneof=short long" desc:"Reports the file of callers by name with short, or by path with long"`
+		CallerTrim      string                 `mapstructure:"caller_trim" desc:"Prefix trimmed from the paths of callers in long mode, such as the module root"`
+		Filename        string                 `mapstructure:"filename" desc:"Path of the log file, <program>-lumberjack.log in the temporary directory when empty"`
+		Levels          map[string]interface{} `mapstructure:"levels" desc:"Levels of named loggers and the loggers below them, such as http.request: warn"`
+		Sampling        SamplingConfig         `mapstructure:"sampling" desc:"Sampling and rate limiting of hot paths"`
+		Redaction       RedactionConfig        `mapstructure:"redaction" desc:"Redaction of sensitive values in fields, payloads and messages, on by default for the values of keys such as authorization, cookie and password"`
+		Async           AsyncConfig            `mapstructure:"async" desc:"Asynchronous writes through a bounded buffer"`
+		Sinks           []SinkConfig           `mapstructure:"sinks" desc:"Outputs of the logger, each with its own level and format, replacing the writer given to Configure"`
+
+		// OnRotate is called with the path of every rotated log file, once compressed
+		OnRotate func(path string) `mapstructure:"-"`
 	}
 
 	Fields map[string]interface{}
+
+	// CallerMode tells how the file of callers is reported
+	CallerMode string
+)
+
+const (
+	// CallerShort reports the file name of callers, such as main.go:12
+	CallerShort CallerMode = "short"
+	// CallerLong reports the path of callers, such as /src/app/main.go:12
+	CallerLong CallerMode = "long"
 )
 
 func init() {
 	instance = newLogrusLogger()
 }
 
+// Configure replaces the package logger with one set up as config sets.
+// Unset values fall back to their defaults, config itself is left as is,
+// and a nil config sets up the defaults.
 func Configure(config *Config, writer io.Writer) {
-	switch config.Base {
-	case "logrus":
-		instance = newLogrusLogger()
+	applied := Config{}
+	if config != nil {
+		applied = *config
 	}
+	config = &applied
+	if err := defaults.Apply(config); err != nil {
+		panic(err.Error())
+	}
+
+	// The summary of the previous logger would otherwise keep running
+	instance.SetSampling(nil)
 
-	if config.Level == "" {
-		config.Level = "debug"
+	previous := configured
+	configured, asyncWriter = nil, nil
+
+	var sinksErr error
+	if len(config.Sinks) > 0 {
+		var sinks *sinkBackend
+		sinks, configured, sinksErr = newSinkBackend(config, writer)
+		instance = newCoreLogger(sinks)
+	} else {
+		instance = newCoreLogger(newBackend(config.Base))
 	}
+
 	instance.SetLevel(config.Level)
+	for name, level := range namedLevels(config.Levels, "") {
+		if err := instance.SetNamedLevel(name, level); err != nil {
+			instance.WithField(NameField, name).Errorf("Ignoring the level of the logger: %v", err)
+		}
+	}
+
+	if err := instance.SetSampling(&config.Sampling); err != nil {
+		panic(err.Error())
+	}
+
+	if err := instance.SetRedaction(&config.Redaction); err != nil {
+		panic(err.Error())
+	}
 
 	instance.SetReportCaller(config.ReportCaller)
 
-	if config.Format == "" {
-		config.Format = "json"
+	if err := instance.SetCallerFormat(config.CallerMode, config.CallerTrim); err != nil {
+		panic(err.Error())
+	}
+
+	// Sinks have their own format and writer
+	if len(config.Sinks) == 0 {
+		instance.SetFormatter(config.Format)
+
+		if config.Enabled {
+			if config.Async.Enabled {
+				var err error
+				if asyncWriter, err = NewAsyncWriter(writer, config.Async.Size, config.Async.Policy); err != nil {
+					panic(err.Error())
+				}
+				configured = append(configured, asyncStopper{asyncWriter})
+				writer = asyncWriter
+			}
+			instance.SetWriter(writer)
+		}
 	}
-	instance.SetFormatter(config.Format)
 
-	if config.Enabled {
-		instance.SetWriter(writer)
+	for _, err := range multierr.Errors(sinksErr) {
+		instance.Errorf("Ignoring the sink: %v", err)
 	}
+
+	// Released once the new logger is in place, so that the buffers of the
+	// previous one are written out
+	if err := release(previous); err != nil {
+		instance.Errorf("Failed to close the previous log outputs: %v", err)
+	}
+}
+
+// Close releases what Configure opened, meant for shutdowns. Buffered entries
+// are written out, then the files and connections of sinks are closed. The
+// writer given to Configure is left open and written to synchronously afterwards.
+func Close() error {
+	if asyncWriter != nil {
+		instance.SetWriter(asyncWriter.writer)
+	}
+	closers := configured
+	configured, asyncWriter = nil, nil
+	return release(closers)
+}
+
+// GetAsyncStats returns the counters of the async writers Configure created,
+// summed over the writer given to Configure and the sinks
+func GetAsyncStats() AsyncStats {
+	var total AsyncStats
+	for _, closer := range configured {
+		if stopper, ok := closer.(asyncStopper); ok {
+			stats := stopper.writer.Stats()
+			total.Queued += stats.Queued
+			total.Written += stats.Written
+			total.Dropped += stats.Dropped
+			total.Failed += stats.Failed
+		}
+	}
+	return total
 }
 
 func Debug(args ...interface{}) {
@@ -205,12 +328,9 @@ func WithContext(ctx context.Context) LogEntry {
 	return instance.WithContext(ctx)
 }
 
+// AddHook attaches hook, a Hook or a logrus.Hook, to the logger whatever its backend
 func AddHook(hook interface{}) error {
-	if logrusHook, ok := hook.(logrus.Hook); ok {
-		instance.AddHook(logrusHook)
-		return nil
-	}
-	return fmt.Errorf("Unsupported hook type attached")
+	return instance.AddHook(hook)
 }
 
 func NewEntry() LogEntry {
@@ -225,10 +345,32 @@ func SetReportCaller(reportCaller bool) {
 	instance.SetReportCaller(reportCaller)
 }
 
+// SetCallerFormat sets how callers are reported: by file name and line with
+// CallerShort, or by path and line with CallerLong, trimmed of the trim prefix
+func SetCallerFormat(mode CallerMode, trim string) error {
+	return instance.SetCallerFormat(mode, trim)
+}
+
+// SetLevel sets the minimum level of the entries of loggers without a level
+// of their own. It panics on an unsupported level.
 func SetLevel(level string) {
 	instance.SetLevel(level)
 }
 
+// SetSampling drops entries as config sets, nil stops sampling
+func SetSampling(config *SamplingConfig) error {
+	return instance.SetSampling(config)
+}
+
+// SetRedaction hides sensitive values as config sets, nil writes entries as they are
+func SetRedaction(config *RedactionConfig) error {
+	return instance.SetRedaction(config)
+}
+
+func GetLevel() Level {
+	return instance.GetLevel()
+}
+
 func SetFormatter(formatter string) {
 	instance.SetFormatter(formatter)
 }
@@ -0,0 +1,23 @@
+package logger_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/PlanckProject/go-commons/logger"
+)
+
+func TestConfigureKeepsConfig(t *testing.T) {
+	t.Cleanup(func() { logger.Configure(&logger.Config{}, os.Stderr) })
+
+	config := &logger.Config{}
+	logger.Configure(config, os.Stderr)
+	if config.Base != "" || config.Level != "" || config.Format != "" {
+		t.Errorf("Configure changed the config to %+v", config)
+	}
+
+	logger.Configure(nil, os.Stderr)
+	if level := logger.GetLevel(); level != logger.DebugLevel {
+		t.Errorf("Level is %v without a config, want debug", level)
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// NameField is the field holding the name of named loggers
+const NameField = "logger"
+
+// levelUpdate is the body of the requests of LevelHandler
+type levelUpdate struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+// levelsResponse is the body of the responses of LevelHandler
+type levelsResponse struct {
+	Level  Level            `json:"level"`
+	Levels map[string]Level `json:"levels"`
+}
+
+// Named returns an entry of the logger name, such as http.request. Its
+// entries have a logger field set to name and are written at the level set
+// for name, or for the closest parent of name, in Config.Levels or with
+// SetNamedLevel. The entry follows the package logger as Configure replaces it.
+func Named(name string) LogEntry {
+	return &coreEntry{name: name, fields: Fields{NameField: name}}
+}
+
+// SetNamedLevel sets the level of the logger name and of the loggers below
+// it. An empty level resets name to the level of its parent.
+func SetNamedLevel(name, level string) error {
+	return instance.SetNamedLevel(name, level)
+}
+
+// NamedLevels returns the levels set for named loggers
+func NamedLevels() map[string]Level {
+	return instance.NamedLevels()
+}
+
+// LevelHandler lists the levels of the package logger as JSON on GET, and
+// updates one on PUT or POST with a body such as
+//
+//	{"name": "http.request", "level": "warn"}
+//
+// An empty name sets the level of the logger itself, an empty level resets
+// a named logger to the level of its parent.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var update levelUpdate
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid level update: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			var err error
+			if update.Name == "" {
+				// SetLevel panics on an unsupported level
+				if _, err = ParseLevel(update.Level); err == nil {
+					SetLevel(update.Level)
+				}
+			} else {
+				err = SetNamedLevel(update.Name, update.Level)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelsResponse{Level: GetLevel(), Levels: NamedLevels()})
+	})
+}
+
+// namedLevels flattens the levels of Config. Dotted names such as http.request
+// are read as nested keys by the config loader, so nested maps are joined back.
+func namedLevels(levels map[string]interface{}, prefix string) map[string]string {
+	flattened := make(map[string]string)
+	for name, value := range levels {
+		fullName := name
+		if prefix != "" {
+			fullName = prefix + "." + name
+		}
+
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			for nestedName, level := range namedLevels(typedValue, fullName) {
+				flattened[nestedName] = level
+			}
+		case map[interface{}]interface{}:
+			nested := make(map[string]interface{}, len(typedValue))
+			for key, nestedValue := range typedValue {
+				nested[fmt.Sprint(key)] = nestedValue
+			}
+			for nestedName, level := range namedLevels(nested, fullName) {
+				flattened[nestedName] = level
+			}
+		default:
+			flattened[fullName] = fmt.Sprint(value)
+		}
+	}
+	return flattened
+}
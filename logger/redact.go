@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values hidden by redaction
+const Redacted = "[REDACTED]"
+
+type (
+	// RedactionConfig hides sensitive values before entries reach hooks and
+	// backends. Fields, nested map keys and JSON payload keys containing one of
+	// Keys are redacted whole, the strings left are scrubbed of the values
+	// matched by Scrubbers. Keys such as authorization and cookie are redacted
+	// unless Disabled is set, while scrubbing, which rewrites messages, is
+	// only done when Scrubbers are set, such as to card,email,jwt.
+	RedactionConfig struct {
+		Disabled  bool     `mapstructure:"disabled" desc:"Writes entries as they are"`
+		Keys      []string `mapstructure:"keys" default:"authorization,cookie,password,passwd,secret,token,api_key,apikey" desc:"Case insensitive parts of the keys whose values are redacted"`
+		Scrubbers []string `mapstructure:"scrubbers" desc:"Values scrubbed from strings, card, email, jwt or a regular expression, none when empty"`
+	}
+
+	redactor struct {
+		keys      []string
+		scrubbers []scrubber
+	}
+
+	scrubber struct {
+		pattern *regexp.Regexp
+		// valid filters the matches of pattern, such as card numbers failing the Luhn check
+		valid func(match string) bool
+	}
+)
+
+var builtinScrubbers = map[string]scrubber{
+	"card":  {pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: isCardNumber},
+	"email": {pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	"jwt":   {pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)},
+}
+
+func newRedactor(config RedactionConfig) (*redactor, error) {
+	r := &redactor{}
+	for _, key := range config.Keys {
+		r.keys = append(r.keys, strings.ToLower(key))
+	}
+	for _, name := range config.Scrubbers {
+		if builtin, ok := builtinScrubbers[name]; ok {
+			r.scrubbers = append(r.scrubbers, builtin)
+			continue
+		}
+		pattern, err := regexp.Compile(name)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid scrubber '%s': %w", name, err)
+		}
+		r.scrubbers = append(r.scrubbers, scrubber{pattern: pattern})
+	}
+	return r, nil
+}
+
+func (r *redactor) redactEntry(entry *Entry) {
+	entry.Message = r.scrub(entry.Message)
+	for key, value := range entry.Fields {
+		if r.sensitive(key) {
+			entry.Fields[key] = Redacted
+		} else {
+			entry.Fields[key] = r.redactValue(value)
+		}
+	}
+}
+
+// redactValue returns value with its sensitive parts hidden. Maps and
+// slices are copied rather than modified, since they belong to the caller.
+func (r *redactor) redactValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if redacted, ok := r.redactJSON(typedValue); ok {
+			return redacted
+		}
+		return r.scrub(typedValue)
+	case []byte:
+		return r.redactValue(string(typedValue))
+	case error:
+		// Errors are kept as such unless their message must be scrubbed
+		if message := typedValue.Error(); r.scrub(message) != message {
+			return r.scrub(message)
+		}
+		return typedValue
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Map:
+		if reflected.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		redacted := make(map[string]interface{}, reflected.Len())
+		iterator := reflected.MapRange()
+		for iterator.Next() {
+			key := iterator.Key().String()
+			if r.sensitive(key) {
+				redacted[key] = Redacted
+			} else {
+				redacted[key] = r.redactValue(iterator.Value().Interface())
+			}
+		}
+		return redacted
+	case reflect.Slice, reflect.Array:
+		redacted := make([]interface{}, reflected.Len())
+		for i := range redacted {
+			redacted[i] = r.redactValue(reflected.Index(i).Interface())
+		}
+		return redacted
+	}
+	return value
+}
+
+// redactJSON redacts the keys of s when it holds a JSON object or array
+func (r *redactor) redactJSON(s string) (string, bool) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return "", false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var payload interface{}
+	if err := decoder.Decode(&payload); err != nil || decoder.More() {
+		return "", false
+	}
+
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r.redactValue(payload)); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), true
+}
+
+func (r *redactor) sensitive(key string) bool {
+	lowered := strings.ToLower(key)
+	for _, sensitiveKey := range r.keys {
+		if strings.Contains(lowered, sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) scrub(s string) string {
+	for _, scrubber := range r.scrubbers {
+		s = scrubber.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if scrubber.valid != nil && !scrubber.valid(match) {
+				return match
+			}
+			return Redacted
+		})
+	}
+	return s
+}
+
+// isCardNumber tells whether the digits of s pass the Luhn check
+func isCardNumber(s string) bool {
+	var sum, count int
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		digit := int(s[i] - '0')
+		if count%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		count++
+	}
+	return count >= 13 && sum%10 == 0
+}
@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PlanckProject/go-commons/config/defaults"
+)
+
+// maxSampledMessages bounds the messages counted in a tick, the ones beyond are counted together
+const maxSampledMessages = 4096
+
+type (
+	// SamplingConfig drops entries of hot paths. Within every tick, the first
+	// entries with the same level and message are written, then 1 in every
+	// thereafter. The entries left are then limited to rate per second with a
+	// token bucket. Fatal and panic entries are never dropped. Summary is a
+	// pointer, so that 0 turns the summary off while nil falls back to the default.
+	SamplingConfig struct {
+		Tick       time.Duration  `mapstructure:"tick" default:"1s" desc:"Window in which entries are counted for sampling"`
+		First      uint           `mapstructure:"first" desc:"Entries written per tick before sampling, sampling is off when 0"`
+		Thereafter uint           `mapstructure:"thereafter" desc:"Writes 1 in every thereafter entries past the first ones, none when 0"`
+		By         string         `mapstructure:"by" default:"message" validate:"oneof=message level" desc:"Counts entries by level and message, or by level alone"`
+		Rate       float64        `mapstructure:"rate" validate:"min=0" desc:"Entries written per second on average, unlimited when 0"`
+		Burst      uint           `mapstructure:"burst" desc:"Entries written at once above the rate, the rate itself when 0"`
+		Levels     []string       `mapstructure:"levels" default:"trace,debug,info" validate:"oneof=trace debug info warn error" desc:"Levels of the entries that may be dropped"`
+		Summary    *time.Duration `mapstructure:"summary" default:"1m" desc:"Interval at which the number of dropped entries is logged, never when 0"`
+	}
+
+	// sampler decides which entries are written under a SamplingConfig
+	sampler struct {
+		config SamplingConfig
+		levels map[Level]bool
+
+		mu        sync.Mutex
+		tickEnd   time.Time
+		counts    map[string]uint64
+		tokens    float64
+		refilled  time.Time
+		dropped   map[Level]uint64
+		stop      chan struct{}
+		closeOnce sync.Once
+	}
+)
+
+func newSampler(config SamplingConfig) (*sampler, error) {
+	// Configs built in code get the defaults Configure gives those it reads
+	if err := defaults.Apply(&config); err != nil {
+		return nil, err
+	}
+
+	s := &sampler{
+		config:   config,
+		levels:   make(map[Level]bool),
+		counts:   make(map[string]uint64),
+		tokens:   config.burst(),
+		refilled: time.Now(),
+		dropped:  make(map[Level]uint64),
+		stop:     make(chan struct{}),
+	}
+	for _, name := range config.Levels {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		s.levels[level] = true
+	}
+	return s, nil
+}
+
+// enabled tells whether config drops any entry
+func (c *SamplingConfig) enabled() bool {
+	return c != nil && (c.First > 0 || c.Rate > 0)
+}
+
+func (c *SamplingConfig) burst() float64 {
+	if c.Burst > 0 {
+		return float64(c.Burst)
+	}
+	if c.Rate < 1 {
+		return 1
+	}
+	return c.Rate
+}
+
+// allow tells whether the entry of the logger name is written, and counts it as dropped otherwise
+func (s *sampler) allow(name string, level Level, message string) bool {
+	if level >= FatalLevel || !s.levels[level] {
+		return true
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sample(now, name, level, message) && s.limit(now) {
+		return true
+	}
+	s.dropped[level]++
+	return false
+}
+
+func (s *sampler) sample(now time.Time, name string, level Level, message string) bool {
+	if s.config.First == 0 {
+		return true
+	}
+
+	if now.After(s.tickEnd) {
+		s.counts = make(map[string]uint64)
+		s.tickEnd = now.Add(s.config.Tick)
+	}
+
+	key := level.String() + "\x00" + name
+	if s.config.By != "level" {
+		key += "\x00" + message
+	}
+	if _, ok := s.counts[key]; !ok && len(s.counts) >= maxSampledMessages {
+		key = level.String()
+	}
+	s.counts[key]++
+
+	count := s.counts[key]
+	if count <= uint64(s.config.First) {
+		return true
+	}
+	return s.config.Thereafter > 0 && (count-uint64(s.config.First))%uint64(s.config.Thereafter) == 0
+}
+
+// limit takes a token from the bucket, refilled at the configured rate
+func (s *sampler) limit(now time.Time) bool {
+	if s.config.Rate <= 0 {
+		return true
+	}
+
+	s.tokens += now.Sub(s.refilled).Seconds() * s.config.Rate
+	if burst := s.config.burst(); s.tokens > burst {
+		s.tokens = burst
+	}
+	s.refilled = now
+
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+// takeDropped returns the entries dropped by level since the last call
+func (s *sampler) takeDropped() map[Level]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := s.dropped
+	s.dropped = make(map[Level]uint64)
+	return dropped
+}
+
+// summarize logs the number of dropped entries through l at every summary interval until closed
+func (s *sampler) summarize(l *coreLogger) {
+	if s.config.Summary == nil || *s.config.Summary <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(*s.config.Summary)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			var total uint64
+			fields := Fields{}
+			for level, count := range s.takeDropped() {
+				fields["dropped."+level.String()] = count
+				total += count
+			}
+			if total == 0 {
+				continue
+			}
+
+			fields["dropped"] = total
+			entry := &coreEntry{logger: l, fields: fields}
+			entry.emit(time.Now(), WarnLevel, "Dropped log entries", nil)
+		}
+	}
+}
+
+func (s *sampler) close() {
+	s.closeOnce.Do(func() { close(s.stop) })
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetSamplingAppliesDefaults(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	Configure(&Config{Enabled: true}, buffer)
+	t.Cleanup(func() { Configure(&Config{}, os.Stderr) })
+
+	if err := SetSampling(&SamplingConfig{First: 2}); err != nil {
+		t.Fatalf("Failed to set sampling: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		Info("hot path")
+	}
+
+	if lines := strings.Count(buffer.String(), "\n"); lines != 2 {
+		t.Errorf("Wrote %d entries, want 2:\n%s", lines, buffer.String())
+	}
+}
+
+func TestSamplingAppliesToSlogRecords(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	Configure(&Config{Enabled: true, Sampling: SamplingConfig{First: 1}}, buffer)
+	t.Cleanup(func() { Configure(&Config{}, os.Stderr) })
+
+	slogger := slog.New(SlogHandler())
+	for i := 0; i < 3; i++ {
+		slogger.Info("hot path")
+	}
+
+	if lines := strings.Count(buffer.String(), "\n"); lines != 1 {
+		t.Errorf("Wrote %d entries, want 1:\n%s", lines, buffer.String())
+	}
+}
+
+func TestSamplingSummaryCanBeTurnedOff(t *testing.T) {
+	off := time.Duration(0)
+	s, err := newSampler(SamplingConfig{First: 1, Summary: &off})
+	if err != nil {
+		t.Fatalf("Failed to create the sampler: %v", err)
+	}
+	if *s.config.Summary != 0 {
+		t.Errorf("Summary is %v, want it off", *s.config.Summary)
+	}
+
+	if s, err = newSampler(SamplingConfig{First: 1}); err != nil {
+		t.Fatalf("Failed to create the sampler: %v", err)
+	}
+	if *s.config.Summary != time.Minute {
+		t.Errorf("Summary is %v, want the default of 1m", *s.config.Summary)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReopen calls reopen on every SIGHUP until the returned func is called
+func notifyReopen(reopen func() error) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if err := reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to reopen log file, %v\n", err)
+				}
+			case <-done:
+				signal.Stop(signals)
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,8 @@
+//go:build windows || plan9
+
+package logger
+
+// notifyReopen does nothing, the platform has no SIGHUP
+func notifyReopen(reopen func() error) func() {
+	return func() {}
+}
@@ -0,0 +1,291 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlanckProject/go-commons/config/defaults"
+	"go.uber.org/multierr"
+)
+
+type (
+	// SinkConfig describes one output of the logger with its own level and format
+	SinkConfig struct {
+		Name     string        `mapstructure:"name" desc:"Identifies the sink in errors, its type when empty"`
+		Type     string        `mapstructure:"type" default:"stdout" validate:"oneof=stdout stderr writer file syslog tcp udp" desc:"Output of the sink, writer being the writer given to Configure"`
+		Level    string        `mapstructure:"level" validate:"omitempty,oneof=trace debug info warn error fatal panic" desc:"Minimum level of the entries written to the sink, every entry of the logger when empty"`
+		Format   string        `mapstructure:"format" validate:"omitempty,oneof=text json" desc:"Format of the entries written to the sink, the format of the logger when empty"`
+		Filename string        `mapstructure:"filename" desc:"Path of the log file of file sinks, rotated as the logger sets"`
+		Address  string        `mapstructure:"address" desc:"Address of tcp and udp collectors, such as localhost:5170, or of the syslog server, such as udp://localhost:514, the local one when empty"`
+		Tag      string        `mapstructure:"tag" desc:"Tag of syslog messages, the name of the program when empty"`
+		Timeout  time.Duration `mapstructure:"timeout" default:"5s" desc:"Timeout of connections and writes to tcp and udp collectors"`
+		Async    AsyncConfig   `mapstructure:"async" desc:"Asynchronous writes to the sink through a bounded buffer"`
+	}
+
+	// sinkBackend writes every entry to several sinks. A sink failing to
+	// write, or panicking, doesn't keep the entry from the others.
+	sinkBackend struct {
+		sinks []*sink
+	}
+
+	sink struct {
+		name    string
+		level   Level
+		backend backend
+		// failing is set once a write failed, until a write succeeds
+		failing int32
+
+		// tagger passes the level of entries to syslog writers, mu keeps it
+		// for the entry written
+		mu     sync.Mutex
+		tagger *levelTagger
+	}
+
+	// levelTagger prefixes every write with the level of the entry written,
+	// which outlives the buffer of an async writer
+	levelTagger struct {
+		writer io.Writer
+		level  Level
+	}
+
+	// netWriter writes to a tcp or udp collector, reconnecting after a
+	// failure, so that a collector down doesn't need a restart. Entries are
+	// dropped without dialing for a timeout after a failed dial.
+	netWriter struct {
+		network string
+		address string
+		timeout time.Duration
+
+		mu      sync.Mutex
+		conn    net.Conn
+		retryAt time.Time
+		dialErr error
+	}
+)
+
+// configured holds what Configure opened, released by Close and by the next Configure
+var configured []io.Closer
+
+// newSinkBackend opens the sinks of config for a logger of the given base.
+// Sinks failing to open are skipped, their errors are returned along with
+// the backend of the others.
+func newSinkBackend(config *Config, writer io.Writer) (*sinkBackend, []io.Closer, error) {
+	b := &sinkBackend{}
+	var closers []io.Closer
+	var errs error
+
+	for i := range config.Sinks {
+		sinkConfig := config.Sinks[i]
+		defaults.Apply(&sinkConfig)
+		if sinkConfig.Name == "" {
+			sinkConfig.Name = sinkConfig.Type
+		}
+
+		s, sinkClosers, err := openSink(config, &sinkConfig, writer)
+		closers = append(closers, sinkClosers...)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("Failed to open sink %s: %w", sinkConfig.Name, err))
+			continue
+		}
+		b.sinks = append(b.sinks, s)
+	}
+	return b, closers, errs
+}
+
+func openSink(config *Config, sinkConfig *SinkConfig, writer io.Writer) (*sink, []io.Closer, error) {
+	s := &sink{name: sinkConfig.Name, level: TraceLevel, backend: newBackend(config.Base)}
+	if sinkConfig.Level != "" {
+		level, err := ParseLevel(sinkConfig.Level)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.level = level
+	}
+
+	format := sinkConfig.Format
+	if format == "" {
+		format = config.Format
+	}
+	if err := s.backend.SetFormat(format); err != nil {
+		return nil, nil, err
+	}
+
+	var closers []io.Closer
+	switch sinkConfig.Type {
+	case "stdout":
+		writer = os.Stdout
+	case "stderr":
+		writer = os.Stderr
+	case "writer":
+		if writer == nil {
+			return nil, nil, fmt.Errorf("No writer given to Configure")
+		}
+	case "file":
+		if sinkConfig.Filename == "" {
+			return nil, nil, fmt.Errorf("No filename set")
+		}
+		rotated := *config
+		rotated.Filename = sinkConfig.Filename
+		writer = GetRotatedWriter(&rotated)
+	case "syslog":
+		syslogWriter, err := newSyslogWriter(sinkConfig.Address, sinkConfig.Tag)
+		if err != nil {
+			return nil, nil, err
+		}
+		writer = syslogWriter
+	case "tcp", "udp":
+		if sinkConfig.Address == "" {
+			return nil, nil, fmt.Errorf("No address set")
+		}
+		writer = &netWriter{network: sinkConfig.Type, address: sinkConfig.Address, timeout: sinkConfig.Timeout}
+	default:
+		return nil, nil, fmt.Errorf("Unsupported sink type '%s'", sinkConfig.Type)
+	}
+	if closer, ok := writer.(io.Closer); ok && sinkConfig.Type != "stdout" && sinkConfig.Type != "stderr" && sinkConfig.Type != "writer" {
+		closers = append(closers, closer)
+	}
+
+	if sinkConfig.Async.Enabled {
+		asyncWriter, err := NewAsyncWriter(writer, sinkConfig.Async.Size, sinkConfig.Async.Policy)
+		if err != nil {
+			return nil, closers, err
+		}
+		// The buffer is written before the writer under it is closed
+		closers = append([]io.Closer{asyncStopper{asyncWriter}}, closers...)
+		writer = asyncWriter
+	}
+
+	// Syslog writes every entry at the severity of its level
+	if sinkConfig.Type == "syslog" {
+		s.tagger = &levelTagger{writer: writer}
+		writer = s.tagger
+	}
+
+	s.backend.SetWriter(writer)
+	return s, closers, nil
+}
+
+func (b *sinkBackend) Write(entry *Entry) error {
+	var errs error
+	for _, s := range b.sinks {
+		if entry.Level >= s.level {
+			errs = multierr.Append(errs, s.write(entry))
+		}
+	}
+	return errs
+}
+
+// SetWriter sends the entries of every sink to writer
+func (b *sinkBackend) SetWriter(writer io.Writer) {
+	for _, s := range b.sinks {
+		s.backend.SetWriter(writer)
+	}
+}
+
+// SetFormat switches the format of every sink
+func (b *sinkBackend) SetFormat(format string) error {
+	for _, s := range b.sinks {
+		if err := s.backend.SetFormat(format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sink) write(entry *Entry) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Sink %s panicked: %v", s.name, recovered)
+		}
+	}()
+
+	if s.tagger != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.tagger.level = entry.Level
+	}
+
+	// A sink down is reported once rather than for every entry
+	if err := s.backend.Write(entry); err != nil {
+		if !atomic.CompareAndSwapInt32(&s.failing, 0, 1) {
+			return nil
+		}
+		return fmt.Errorf("Failed to write to sink %s until it recovers: %w", s.name, err)
+	}
+	atomic.StoreInt32(&s.failing, 0)
+	return nil
+}
+
+func (t *levelTagger) Write(p []byte) (int, error) {
+	tagged := make([]byte, 0, len(p)+1)
+	tagged = append(append(tagged, byte(t.level-TraceLevel)), p...)
+	n, err := t.writer.Write(tagged)
+	if n > 0 {
+		n--
+	}
+	return n, err
+}
+
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		if time.Now().Before(w.retryAt) {
+			return 0, w.dialErr
+		}
+		conn, err := net.DialTimeout(w.network, w.address, w.timeout)
+		if err != nil {
+			w.retryAt, w.dialErr = time.Now().Add(w.timeout), err
+			return 0, err
+		}
+		w.conn = conn
+	}
+
+	if w.timeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+	n, err := w.conn.Write(p)
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	return n, err
+}
+
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// asyncStopper stops an AsyncWriter without closing the writer under it
+type asyncStopper struct {
+	writer *AsyncWriter
+}
+
+func (s asyncStopper) Close() error {
+	err := s.writer.Flush()
+	s.writer.stop()
+	return err
+}
+
+// release closes what Configure opened, in order
+func release(closers []io.Closer) error {
+	var errs error
+	for _, closer := range closers {
+		errs = multierr.Append(errs, closer.Close())
+	}
+	return errs
+}
@@ -0,0 +1,76 @@
+package logger_test
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PlanckProject/go-commons/logger"
+)
+
+func TestSyslogSinkSeverity(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer server.Close()
+
+	logger.Configure(&logger.Config{Sinks: []logger.SinkConfig{
+		{Type: "syslog", Address: "udp://" + server.LocalAddr().String(), Tag: "test"},
+	}}, nil)
+	t.Cleanup(func() { logger.Configure(&logger.Config{}, os.Stderr) })
+
+	logger.Error("error")
+	logger.Warn("warn")
+	logger.Info("info")
+	logger.Debug("debug")
+
+	// The priority is the user facility times 8 plus the severity
+	for _, priority := range []string{"<11>", "<12>", "<14>", "<15>"} {
+		buffer := make([]byte, 4096)
+		server.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := server.ReadFrom(buffer)
+		if err != nil {
+			t.Fatalf("Failed to read the %s message: %v", priority, err)
+		}
+		if message := string(buffer[:n]); !strings.HasPrefix(message, priority) {
+			t.Errorf("Message %q, want priority %s", message, priority)
+		}
+	}
+}
+
+func TestSinkDownReportedOnce(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatalf("Failed to create the stderr file: %v", err)
+	}
+	original := os.Stderr
+	os.Stderr = stderr
+	defer func() { os.Stderr = original }()
+
+	logger.Configure(&logger.Config{Sinks: []logger.SinkConfig{
+		{Type: "tcp", Address: address, Timeout: time.Second},
+	}}, nil)
+	t.Cleanup(func() { logger.Configure(&logger.Config{}, os.Stderr) })
+
+	for i := 0; i < 5; i++ {
+		logger.Info("entry")
+	}
+
+	output, err := os.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatalf("Failed to read the stderr file: %v", err)
+	}
+	if reports := strings.Count(string(output), "\n"); reports != 1 {
+		t.Errorf("Reported %d failures, want 1:\n%s", reports, output)
+	}
+}
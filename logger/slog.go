@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+)
+
+var slogLevels = map[Level]slog.Level{
+	TraceLevel: slog.LevelDebug - 4,
+	DebugLevel: slog.LevelDebug,
+	InfoLevel:  slog.LevelInfo,
+	WarnLevel:  slog.LevelWarn,
+	ErrorLevel: slog.LevelError,
+	FatalLevel: slog.LevelError + 4,
+	PanicLevel: slog.LevelError + 8,
+}
+
+// NewSlogLogger returns a Logger writing through handler. The writer and the
+// format of handler can't be changed, so SetWriter and SetFormatter are
+// ignored. Levels without a slog counterpart are written as DEBUG-4 for
+// trace, ERROR+4 for fatal and ERROR+8 for panic.
+func NewSlogLogger(handler slog.Handler) Logger {
+	return newCoreLogger(&slogBackend{handler: handler, wrapped: true})
+}
+
+// slogBackend encodes entries with the JSON and text handlers of log/slog,
+// or with any handler it wraps
+type slogBackend struct {
+	mu      sync.RWMutex
+	writer  io.Writer
+	format  string
+	handler slog.Handler
+	wrapped bool
+}
+
+func newSlogBackend() *slogBackend {
+	b := &slogBackend{writer: os.Stderr, format: "json"}
+	b.build()
+	return b
+}
+
+func (b *slogBackend) Write(entry *Entry) error {
+	record := slog.NewRecord(entry.Time, slogLevels[entry.Level], entry.Message, 0)
+
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		record.AddAttrs(slog.Any(key, entry.Fields[key]))
+	}
+
+	// Wrapped handlers get the source attribute they may expect, the caller
+	// is otherwise written under the keys of the other backends
+	if entry.Caller != nil && b.wrapped {
+		record.AddAttrs(slog.Any(slog.SourceKey, &slog.Source{
+			Function: entry.Caller.Function,
+			File:     entry.Caller.File,
+			Line:     entry.Caller.Line,
+		}))
+	} else if entry.Caller != nil {
+		record.AddAttrs(
+			slog.String(callerFileKey, fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)),
+			slog.String(callerFunctionKey, entry.Caller.Function),
+		)
+	}
+
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if !b.handler.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return b.handler.Handle(ctx, record)
+}
+
+func (b *slogBackend) SetWriter(writer io.Writer) {
+	if b.wrapped {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writer = writer
+	b.build()
+}
+
+func (b *slogBackend) SetFormat(format string) error {
+	if format != "text" && format != "json" {
+		return unsupportedFormatError(format)
+	}
+	if b.wrapped {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.format = format
+	b.build()
+	return nil
+}
+
+// build creates the handler for the current writer and format, b.mu must be held
+func (b *slogBackend) build() {
+	options := &slog.HandlerOptions{
+		Level:       slogLevels[TraceLevel],
+		ReplaceAttr: replaceSlogLevel,
+	}
+	if b.format == "text" {
+		b.handler = slog.NewTextHandler(b.writer, options)
+	} else {
+		b.handler = slog.NewJSONHandler(b.writer, options)
+	}
+}
+
+// replaceSlogLevel writes levels with the names of the other backends
+func replaceSlogLevel(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.LevelKey {
+		if level, ok := attr.Value.Any().(slog.Level); ok {
+			attr.Value = slog.StringValue(fromSlogLevel(level).String())
+		}
+	}
+	return attr
+}
+
+// fromSlogLevel maps slog levels, which may lie between the named ones, onto the closest level below
+func fromSlogLevel(level slog.Level) Level {
+	result := TraceLevel
+	for _, candidate := range AllLevels {
+		if slogLevels[candidate] <= level {
+			result = candidate
+		}
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"io"
+	"log/syslog"
+	"net/url"
+)
+
+// syslogWriter writes entries at the severity of their level, tagged as the
+// first byte of every write by a levelTagger
+type syslogWriter struct {
+	writer *syslog.Writer
+}
+
+// newSyslogWriter connects to the syslog server at address, such as
+// udp://localhost:514, or to the local one when address is empty
+func newSyslogWriter(address, tag string) (io.Writer, error) {
+	var network, host string
+	if address != "" {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, err
+		}
+		network, host = u.Scheme, u.Host
+	}
+	writer, err := syslog.Dial(network, host, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogWriter{writer: writer}, nil
+}
+
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	message := string(p[1:])
+	var err error
+	switch Level(p[0]) + TraceLevel {
+	case FatalLevel, PanicLevel:
+		err = w.writer.Crit(message)
+	case ErrorLevel:
+		err = w.writer.Err(message)
+	case WarnLevel:
+		err = w.writer.Warning(message)
+	case InfoLevel:
+		err = w.writer.Info(message)
+	default:
+		err = w.writer.Debug(message)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *syslogWriter) Close() error {
+	return w.writer.Close()
+}
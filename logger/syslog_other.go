@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"fmt"
+	"io"
+)
+
+func newSyslogWriter(address, tag string) (io.Writer, error) {
+	return nil, fmt.Errorf("Syslog is not supported on this platform")
+}
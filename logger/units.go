@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlanckProject/go-commons/types"
+)
+
+const day = 24 * time.Hour
+
+type (
+	// Megabytes is a size in megabytes of 1024 * 1024 bytes. It's written as a
+	// number of megabytes, as in older configs, or as a byte size such as
+	// "100MiB" or "1GiB", rounded up to whole megabytes.
+	Megabytes uint
+
+	// Days is a number of days. It's written as a number of days, as in older
+	// configs, or as a duration such as "168h" or "7d", rounded up to whole days.
+	Days uint
+)
+
+func (m *Megabytes) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if megabytes, err := strconv.ParseUint(value, 10, 64); err == nil {
+		*m = Megabytes(megabytes)
+		return nil
+	}
+
+	size, err := types.ParseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*m = Megabytes(math.Ceil(float64(size) / float64(types.MiB)))
+	return nil
+}
+
+func (m Megabytes) String() string {
+	return fmt.Sprintf("%dMiB", uint(m))
+}
+
+func (d *Days) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if days, err := strconv.ParseUint(value, 10, 64); err == nil {
+		*d = Days(days)
+		return nil
+	}
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(value, "d"), 64)
+		if err != nil {
+			return fmt.Errorf("Invalid number of days %q", value)
+		}
+		*d = Days(math.Ceil(days))
+		return nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("Invalid number of days %q", value)
+	}
+	*d = Days(math.Ceil(float64(duration) / float64(day)))
+	return nil
+}
+
+func (d Days) String() string {
+	return fmt.Sprintf("%dd", uint(d))
+}
@@ -1,17 +1,550 @@
 package logger
 
 import (
+	"compress/gzip"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/natefinch/lumberjack"
+	"github.com/PlanckProject/go-commons/types"
 )
 
+const (
+	// defaultMaxSize is the size in megabytes at which log files are rotated when MaxSize is 0
+	defaultMaxSize = 100
+	// backupTimeFormat stamps the rotated files named after Filename
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+	megabyte         = int64(types.MiB)
+)
+
+// backup is a rotated log file
+type backup struct {
+	os.FileInfo
+	path string
+}
+
+var patternVerbs = map[byte]string{
+	'Y': "2006",
+	'm': "01",
+	'd': "02",
+	'H': "15",
+	'M': "04",
+	'S': "05",
+}
+
+// RotatedWriter writes to a log file rotated once it reaches its maximum
+// size, and every hour or day with hourly or daily rotation. Rotated files
+// are compressed and removed in the background, by count, by age and once
+// they use more than the maximum total size. The log file is opened on the
+// first write, and reopened on the first write after Close or Reopen.
+type RotatedWriter struct {
+	filename     string
+	pattern      string
+	every        string
+	maxSize      int64
+	maxBackups   int
+	maxAge       time.Duration
+	maxTotalSize int64
+	compress     bool
+	onRotate     func(path string)
+
+	mu          sync.Mutex
+	file        *os.File
+	size        int64
+	periodStart time.Time
+	periodEnd   time.Time
+	stopSignals func()
+	// rotated holds the rotated files left for mill, oldest first
+	rotated []string
+
+	// millMu runs the compression and removal of rotated files one at a time
+	millMu sync.Mutex
+}
+
+// GetRotatedWriter returns a *RotatedWriter for the log file of config.
+// With ReopenOnSIGHUP, it reopens the file on SIGHUP until it's closed.
 func GetRotatedWriter(config *Config) io.Writer {
-	return &lumberjack.Logger{
-		Filename:   config.Filename,
-		MaxSize:    int(config.MaxSize),
-		MaxBackups: int(config.MaxBackups),
-		MaxAge:     int(config.MaxAge),
-		Compress:   config.Compress,
+	maxSize := int64(config.MaxSize)
+	if maxSize == 0 {
+		maxSize = defaultMaxSize
+	}
+
+	// The file lumberjack wrote to without a filename
+	filename := config.Filename
+	if filename == "" {
+		filename = filepath.Join(os.TempDir(), filepath.Base(os.Args[0])+"-lumberjack.log")
+	}
+
+	w := &RotatedWriter{
+		filename:     filename,
+		pattern:      config.FilenamePattern,
+		every:        config.Rotation,
+		maxSize:      maxSize * megabyte,
+		maxBackups:   int(config.MaxBackups),
+		maxAge:       time.Duration(config.MaxAge) * day,
+		maxTotalSize: int64(config.MaxTotalSize) * megabyte,
+		compress:     config.Compress,
+		onRotate:     config.OnRotate,
+	}
+	if config.ReopenOnSIGHUP {
+		w.stopSignals = notifyReopen(w.Reopen)
+	}
+	return w
+}
+
+func (w *RotatedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if int64(len(p)) > w.maxSize {
+		return 0, fmt.Errorf("Entry of %d bytes exceeds the maximum log file size of %d bytes", len(p), w.maxSize)
+	}
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now()
+	if (w.every != "" && !now.Before(w.periodEnd)) || w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the log file right away
+func (w *RotatedWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	return w.rotate(time.Now())
+}
+
+// Reopen closes the log file, so that the next write opens the file at its
+// path again. It lets logrotate move the file away and signal the writer.
+func (w *RotatedWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closeFile()
+}
+
+// Close closes the log file and stops reopening it on SIGHUP
+func (w *RotatedWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopSignals != nil {
+		w.stopSignals()
+		w.stopSignals = nil
 	}
+	return w.closeFile()
+}
+
+// Sync commits the log file to disk
+func (w *RotatedWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// open opens the log file for appending. The period of an existing file
+// starts with its last write, so that a file left from a previous period is
+// rotated before it's written to.
+func (w *RotatedWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0755); err != nil {
+		return fmt.Errorf("Failed to create the directory of log file %s: %w", w.filename, err)
+	}
+
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to open log file %s: %w", w.filename, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to open log file %s: %w", w.filename, err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	if w.every != "" && w.size > 0 {
+		w.setPeriod(info.ModTime())
+	} else {
+		w.setPeriod(time.Now())
+	}
+	return nil
+}
+
+// rotate moves the log file to its rotated name and opens a new one. The
+// rotated file is handed over to mill.
+func (w *RotatedWriter) rotate(now time.Time) error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	rotated := w.rotatedName(now)
+	if err := os.MkdirAll(filepath.Dir(rotated), 0755); err != nil {
+		return fmt.Errorf("Failed to create the directory of rotated log file %s: %w", rotated, err)
+	}
+
+	// The log file may have been moved away already
+	_, statErr := os.Stat(w.filename)
+	movedAway := os.IsNotExist(statErr)
+	if !movedAway {
+		if err := os.Rename(w.filename, rotated); err != nil {
+			return fmt.Errorf("Failed to rotate log file %s: %w", w.filename, err)
+		}
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	if !movedAway {
+		w.rotated = append(w.rotated, rotated)
+		go w.mill()
+	}
+	return nil
+}
+
+func (w *RotatedWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotatedWriter) setPeriod(t time.Time) {
+	switch w.every {
+	case "hourly":
+		w.periodStart = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		w.periodEnd = w.periodStart.Add(time.Hour)
+	case "daily":
+		w.periodStart = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		w.periodEnd = w.periodStart.AddDate(0, 0, 1)
+	default:
+		w.periodStart = t
+	}
+}
+
+// rotatedName returns the free name the log file is rotated to. The pattern
+// is expanded with the start of the period of the file, Filename is stamped
+// with the time of the rotation in UTC.
+func (w *RotatedWriter) rotatedName(now time.Time) string {
+	var name string
+	if w.pattern != "" {
+		name = expandPattern(w.pattern, w.periodStart)
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(w.filename), name)
+		}
+	} else {
+		extension := filepath.Ext(w.filename)
+		name = strings.TrimSuffix(w.filename, extension) + "-" + now.UTC().Format(backupTimeFormat) + extension
+	}
+
+	// Files rotated by size within the same period share their pattern
+	extension := filepath.Ext(name)
+	candidate := name
+	for i := 1; exists(candidate) || exists(candidate+compressSuffix); i++ {
+		candidate = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, extension), i, extension)
+	}
+	return candidate
+}
+
+// mill compresses the rotated files and passes them to the rotation
+// callback in order, then removes the rotated files beyond the retention limits
+func (w *RotatedWriter) mill() {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	w.mu.Lock()
+	rotated := w.rotated
+	w.rotated = nil
+	w.mu.Unlock()
+
+	for _, path := range rotated {
+		if w.compress {
+			if err := compressFile(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to compress log file %s, %v\n", path, err)
+			} else {
+				path += compressSuffix
+			}
+		}
+
+		if w.onRotate != nil {
+			w.onRotate(path)
+		}
+	}
+
+	if err := w.removeBackups(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to remove rotated log files, %v\n", err)
+	}
+}
+
+// removeBackups removes the oldest rotated files beyond MaxBackups, MaxAge and MaxTotalSize
+func (w *RotatedWriter) removeBackups() error {
+	if w.maxBackups == 0 && w.maxAge == 0 && w.maxTotalSize == 0 {
+		return nil
+	}
+
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	cutoff := time.Now().Add(-w.maxAge)
+	for i, backup := range backups {
+		total += backup.Size()
+		expired := (w.maxBackups > 0 && i >= w.maxBackups) ||
+			(w.maxAge > 0 && backup.ModTime().Before(cutoff)) ||
+			(w.maxTotalSize > 0 && total > w.maxTotalSize)
+		if !expired {
+			continue
+		}
+		if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		// Directories of past periods are removed once empty
+		if dir := filepath.Dir(backup.path); dir != filepath.Dir(w.filename) {
+			os.Remove(dir)
+		}
+	}
+	return nil
+}
+
+// backups returns the rotated files of the log file, newest first. Other
+// files sharing its prefix, such as app-audit.log next to app.log, are left out.
+func (w *RotatedWriter) backups() ([]backup, error) {
+	var backups []backup
+	for _, dir := range w.backupDirs() {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() || path == filepath.Clean(w.filename) || !w.isBackup(entry.Name()) {
+				continue
+			}
+			if info, err := entry.Info(); err == nil {
+				backups = append(backups, backup{path: path, FileInfo: info})
+			}
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ModTime().After(backups[j].ModTime()) })
+	return backups, nil
+}
+
+// backupDirs returns the directories the log file is rotated to, those of
+// every period when the directory of the pattern has verbs, such as %Y/%m/app.log
+func (w *RotatedWriter) backupDirs() []string {
+	if w.pattern == "" {
+		return []string{filepath.Dir(w.filename)}
+	}
+
+	// Relative patterns are expanded apart from the directory of the log file
+	var root string
+	if !filepath.IsAbs(w.pattern) {
+		root = filepath.Dir(w.filename)
+	}
+	dirPattern := filepath.Dir(w.pattern)
+	if !hasVerbs(dirPattern) {
+		return []string{filepath.Join(root, replaceVerbs(dirPattern, func(layout string) string { return layout }))}
+	}
+
+	glob := filepath.Join(escapeGlob(root), replaceVerbs(escapeGlob(dirPattern), func(string) string { return "*" }))
+	candidates, _ := filepath.Glob(glob)
+	var dirs []string
+	for _, candidate := range candidates {
+		relative := candidate
+		if root != "" {
+			relative, _ = filepath.Rel(root, candidate)
+		}
+		if matchesPattern(dirPattern, relative) {
+			dirs = append(dirs, candidate)
+		}
+	}
+	return dirs
+}
+
+// isBackup tells whether name is one rotatedName returns, compressed or not
+func (w *RotatedWriter) isBackup(name string) bool {
+	name = strings.TrimSuffix(name, compressSuffix)
+	if w.pattern != "" {
+		return matchesPattern(filepath.Base(w.pattern), name) ||
+			matchesPattern(filepath.Base(w.pattern), trimNumber(name))
+	}
+
+	extension := filepath.Ext(w.filename)
+	prefix := strings.TrimSuffix(filepath.Base(w.filename), extension) + "-"
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, extension) {
+		return false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), extension)
+	if len(stamp) > len(backupTimeFormat) {
+		stamp = strings.TrimSuffix(trimNumber(stamp+extension), extension)
+	}
+	_, err := time.Parse(backupTimeFormat, stamp)
+	return err == nil
+}
+
+// expandPattern replaces the %Y, %m, %d, %H, %M and %S of pattern with t and %% with %
+func expandPattern(pattern string, t time.Time) string {
+	return replaceVerbs(pattern, func(layout string) string { return t.Format(layout) })
+}
+
+// escapeGlob escapes the characters filepath.Match gives a meaning to,
+// which can't be escaped on Windows where \ separates paths
+func escapeGlob(path string) string {
+	if runtime.GOOS == "windows" {
+		return path
+	}
+
+	var builder strings.Builder
+	for _, c := range path {
+		if strings.ContainsRune(`*?[\`, c) {
+			builder.WriteByte('\\')
+		}
+		builder.WriteRune(c)
+	}
+	return builder.String()
+}
+
+// hasVerbs tells whether pattern has any of %Y, %m, %d, %H, %M and %S
+func hasVerbs(pattern string) bool {
+	found := false
+	replaceVerbs(pattern, func(string) string {
+		found = true
+		return ""
+	})
+	return found
+}
+
+// matchesPattern tells whether pattern expands to name, with digits for its
+// %Y, %m, %d, %H, %M and %S
+func matchesPattern(pattern, name string) bool {
+	var layout strings.Builder
+	expanded := replaceVerbs(pattern, func(verbLayout string) string {
+		layout.WriteString(verbLayout)
+		return strings.Repeat("\x00", len(verbLayout))
+	})
+	if len(expanded) != len(name) {
+		return false
+	}
+
+	var digits strings.Builder
+	for i := 0; i < len(expanded); i++ {
+		switch {
+		case expanded[i] == 0 && name[i] >= '0' && name[i] <= '9':
+			digits.WriteByte(name[i])
+		case expanded[i] != name[i]:
+			return false
+		}
+	}
+	_, err := time.Parse(layout.String(), digits.String())
+	return err == nil
+}
+
+// trimNumber removes the numbered suffix rotatedName gives names already taken,
+// such as the -1 of app-2024-01-02-1.log
+func trimNumber(name string) string {
+	extension := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, extension)
+	i := strings.LastIndexByte(stem, '-')
+	if i < 0 || i == len(stem)-1 {
+		return name
+	}
+	for _, c := range stem[i+1:] {
+		if c < '0' || c > '9' {
+			return name
+		}
+	}
+	return stem[:i] + extension
+}
+
+func replaceVerbs(pattern string, replace func(layout string) string) string {
+	var builder strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i == len(pattern)-1 {
+			builder.WriteByte(pattern[i])
+			continue
+		}
+
+		i++
+		if layout, ok := patternVerbs[pattern[i]]; ok {
+			builder.WriteString(replace(layout))
+		} else if pattern[i] == '%' {
+			builder.WriteByte('%')
+		} else {
+			builder.WriteByte('%')
+			builder.WriteByte(pattern[i])
+		}
+	}
+	return builder.String()
+}
+
+// compressFile gzips path next to it and removes it
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	compressor := gzip.NewWriter(destination)
+	if _, err := io.Copy(compressor, source); err != nil {
+		destination.Close()
+		os.Remove(path + compressSuffix)
+		return err
+	}
+	if err := compressor.Close(); err != nil {
+		destination.Close()
+		os.Remove(path + compressSuffix)
+		return err
+	}
+	if err := destination.Close(); err != nil {
+		return err
+	}
+
+	source.Close()
+	return os.Remove(path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
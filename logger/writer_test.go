@@ -0,0 +1,305 @@
+package logger_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PlanckProject/go-commons/logger"
+)
+
+func TestRotatedWriterRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	rotated := make(chan string, 1)
+	w := rotatedWriter(t, &logger.Config{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxSize:  1,
+		OnRotate: func(path string) { rotated <- path },
+	})
+
+	entry := bytes.Repeat([]byte("a"), 300*1024)
+	for i := 0; i < 4; i++ {
+		write(t, w, entry)
+	}
+
+	path := waitRotation(t, rotated)
+	if info, err := os.Stat(path); err != nil || info.Size() != 3*int64(len(entry)) {
+		t.Errorf("Rotated file %s has %v, want 3 entries", path, info)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "app.log")); err != nil || info.Size() != int64(len(entry)) {
+		t.Errorf("Log file has %v, want 1 entry", info)
+	}
+}
+
+func TestRotatedWriterKeepsSiblingFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app-audit.log", "app-1.log", "app-2024.log.gz"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("sibling"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	rotated := make(chan string, 3)
+	w := rotatedWriter(t, &logger.Config{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxBackups: 1,
+		OnRotate:   func(path string) { rotated <- path },
+	})
+	for i := 0; i < 3; i++ {
+		write(t, w, []byte("entry\n"))
+		rotate(t, w)
+		waitRotation(t, rotated)
+	}
+
+	eventually(t, func() bool { return len(files(t, dir)) == 5 })
+	names := files(t, dir)
+	for _, sibling := range []string{"app-audit.log", "app-1.log", "app-2024.log.gz"} {
+		if !contains(names, sibling) {
+			t.Errorf("Sibling %s was removed, files are %v", sibling, names)
+		}
+	}
+}
+
+func TestRotatedWriterRemovesByTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	rotated := make(chan string, 3)
+	w := rotatedWriter(t, &logger.Config{
+		Filename:     filepath.Join(dir, "app.log"),
+		MaxTotalSize: 1,
+		OnRotate:     func(path string) { rotated <- path },
+	})
+
+	entry := bytes.Repeat([]byte("a"), 400*1024)
+	for i := 0; i < 3; i++ {
+		write(t, w, entry)
+		rotate(t, w)
+		waitRotation(t, rotated)
+	}
+
+	// Two backups of 400 KiB fit in 1 MiB, the oldest one doesn't
+	eventually(t, func() bool { return len(files(t, dir)) == 3 })
+}
+
+func TestRotatedWriterRotatesOldFileDaily(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(filename, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("Failed to write the log file: %v", err)
+	}
+	old := time.Now().AddDate(0, 0, -2)
+	if err := os.Chtimes(filename, old, old); err != nil {
+		t.Fatalf("Failed to age the log file: %v", err)
+	}
+
+	rotated := make(chan string, 1)
+	w := rotatedWriter(t, &logger.Config{
+		Filename:        filename,
+		Rotation:        "daily",
+		FilenamePattern: "app-%Y-%m-%d.log",
+		OnRotate:        func(path string) { rotated <- path },
+	})
+	write(t, w, []byte("new\n"))
+
+	want := filepath.Join(dir, "app-"+old.Format("2006-01-02")+".log")
+	if path := waitRotation(t, rotated); path != want {
+		t.Errorf("Rotated to %s, want %s", path, want)
+	}
+	if content, _ := os.ReadFile(want); string(content) != "old\n" {
+		t.Errorf("Rotated file holds %q, want the old entry", content)
+	}
+	if content, _ := os.ReadFile(filename); string(content) != "new\n" {
+		t.Errorf("Log file holds %q, want the new entry", content)
+	}
+}
+
+func TestRotatedWriterNumbersPatternNames(t *testing.T) {
+	dir := t.TempDir()
+	rotated := make(chan string, 2)
+	w := rotatedWriter(t, &logger.Config{
+		Filename:        filepath.Join(dir, "app.log"),
+		FilenamePattern: "app-%Y-%m-%d.log",
+		MaxBackups:      2,
+		OnRotate:        func(path string) { rotated <- path },
+	})
+
+	var paths []string
+	for i := 0; i < 2; i++ {
+		write(t, w, []byte("entry\n"))
+		rotate(t, w)
+		paths = append(paths, filepath.Base(waitRotation(t, rotated)))
+	}
+
+	day := time.Now().Format("2006-01-02")
+	if want := []string{"app-" + day + ".log", "app-" + day + "-1.log"}; strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("Rotated to %v, want %v", paths, want)
+	}
+}
+
+func TestRotatedWriterRotatesIntoPatternDirectories(t *testing.T) {
+	now := time.Now()
+	patterns := map[string]string{
+		"archive/app-%Y-%m-%d.log": filepath.Join("archive", "app-"+now.Format("2006-01-02")+".log"),
+		"%Y/%m/app.log":            filepath.Join(now.Format("2006"), now.Format("01"), "app.log"),
+	}
+	for pattern, first := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			dir := t.TempDir()
+			rotated := make(chan string, 3)
+			w := rotatedWriter(t, &logger.Config{
+				Filename:        filepath.Join(dir, "app.log"),
+				FilenamePattern: pattern,
+				MaxBackups:      1,
+				OnRotate:        func(path string) { rotated <- path },
+			})
+
+			for i := 0; i < 3; i++ {
+				write(t, w, []byte("entry\n"))
+				rotate(t, w)
+				path := waitRotation(t, rotated)
+				if want := filepath.Join(dir, first); i == 0 && path != want {
+					t.Errorf("Rotated to %s, want %s", path, want)
+				}
+			}
+
+			// Only the last backup is kept, next to the log file left empty
+			backupDir := filepath.Join(dir, filepath.Dir(first))
+			eventually(t, func() bool { return len(files(t, backupDir)) == 1 })
+			if info, err := os.Stat(filepath.Join(dir, "app.log")); err != nil || info.Size() != 0 {
+				t.Errorf("Log file has %v, want an empty one", info)
+			}
+		})
+	}
+}
+
+func TestRotatedWriterCompresses(t *testing.T) {
+	dir := t.TempDir()
+	rotated := make(chan string, 1)
+	w := rotatedWriter(t, &logger.Config{
+		Filename: filepath.Join(dir, "app.log"),
+		Compress: true,
+		OnRotate: func(path string) { rotated <- path },
+	})
+	write(t, w, []byte("entry\n"))
+	rotate(t, w)
+
+	path := waitRotation(t, rotated)
+	if !strings.HasSuffix(path, ".gz") {
+		t.Fatalf("Rotated to %s, want a compressed file", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if content, _ := io.ReadAll(reader); string(content) != "entry\n" {
+		t.Errorf("Compressed file holds %q, want the entry", content)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(path, ".gz")); !os.IsNotExist(err) {
+		t.Errorf("Uncompressed file left next to %s", path)
+	}
+}
+
+func TestRotatedWriterReopens(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	w := rotatedWriter(t, &logger.Config{Filename: filename})
+	write(t, w, []byte("first\n"))
+
+	// As logrotate moves the file before signaling
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatalf("Failed to move the log file: %v", err)
+	}
+	if err := w.Reopen(); err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	write(t, w, []byte("second\n"))
+
+	if content, _ := os.ReadFile(filename + ".1"); string(content) != "first\n" {
+		t.Errorf("Moved file holds %q, want the first entry", content)
+	}
+	if content, _ := os.ReadFile(filename); string(content) != "second\n" {
+		t.Errorf("Reopened file holds %q, want the second entry", content)
+	}
+}
+
+func TestRotatedWriterDefaultsFilename(t *testing.T) {
+	w := rotatedWriter(t, &logger.Config{})
+	write(t, w, []byte("entry\n"))
+
+	filename := filepath.Join(os.TempDir(), filepath.Base(os.Args[0])+"-lumberjack.log")
+	t.Cleanup(func() { os.Remove(filename) })
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf("No log file at %s: %v", filename, err)
+	}
+}
+
+func rotatedWriter(t *testing.T, config *logger.Config) *logger.RotatedWriter {
+	w := logger.GetRotatedWriter(config).(*logger.RotatedWriter)
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func write(t *testing.T, w io.Writer, p []byte) {
+	if _, err := w.Write(p); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+}
+
+func rotate(t *testing.T, w *logger.RotatedWriter) {
+	if err := w.Rotate(); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+}
+
+func waitRotation(t *testing.T, rotated <-chan string) string {
+	select {
+	case path := <-rotated:
+		return path
+	case <-time.After(time.Second):
+		t.Fatal("No file rotated")
+		return ""
+	}
+}
+
+// eventually waits for the rotated files to be removed in the background
+func eventually(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func files(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(names []string, name string) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// zapTraceLevel is below zap's own levels, which stop at debug
+const zapTraceLevel = zapcore.DebugLevel - 1
+
+var zapLevels = map[Level]zapcore.Level{
+	TraceLevel: zapTraceLevel,
+	DebugLevel: zapcore.DebugLevel,
+	InfoLevel:  zapcore.InfoLevel,
+	WarnLevel:  zapcore.WarnLevel,
+	ErrorLevel: zapcore.ErrorLevel,
+	FatalLevel: zapcore.FatalLevel,
+	PanicLevel: zapcore.PanicLevel,
+}
+
+// zapBackend encodes entries with the encoders of zap. Its core accepts
+// every level, fatal and panic entries are handled by the logger.
+type zapBackend struct {
+	mu     sync.RWMutex
+	writer io.Writer
+	format string
+	core   zapcore.Core
+}
+
+func newZapBackend() *zapBackend {
+	b := &zapBackend{writer: os.Stderr, format: "json"}
+	b.build()
+	return b
+}
+
+func (b *zapBackend) Write(entry *Entry) error {
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zapcore.Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, zap.Any(key, entry.Fields[key]))
+	}
+
+	zapEntry := zapcore.Entry{
+		Level:   zapLevels[entry.Level],
+		Time:    entry.Time,
+		Message: entry.Message,
+	}
+	if entry.Caller != nil {
+		zapEntry.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       entry.Caller.PC,
+			File:     entry.Caller.File,
+			Line:     entry.Caller.Line,
+			Function: entry.Caller.Function,
+		}
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.core.Write(zapEntry, fields)
+}
+
+func (b *zapBackend) SetWriter(writer io.Writer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writer = writer
+	b.build()
+}
+
+func (b *zapBackend) SetFormat(format string) error {
+	if format != "text" && format != "json" {
+		return unsupportedFormatError(format)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.format = format
+	b.build()
+	return nil
+}
+
+// build creates the core for the current writer and format, b.mu must be held
+func (b *zapBackend) build() {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		MessageKey:     messageKey,
+		CallerKey:      callerFileKey,
+		FunctionKey:    callerFunctionKey,
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    encodeZapLevel,
+		EncodeTime:     zapcore.RFC3339TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.FullCallerEncoder,
+	}
+
+	var encoder zapcore.Encoder
+	if b.format == "text" {
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	} else {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	}
+
+	allLevels := zap.LevelEnablerFunc(func(zapcore.Level) bool { return true })
+	b.core = zapcore.NewCore(encoder, zapcore.Lock(zapcore.AddSync(b.writer)), allLevels)
+}
+
+func encodeZapLevel(level zapcore.Level, encoder zapcore.PrimitiveArrayEncoder) {
+	if level == zapTraceLevel {
+		encoder.AppendString(TraceLevel.String())
+		return
+	}
+	zapcore.LowercaseLevelEncoder(level, encoder)
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var zerologLevels = map[Level]zerolog.Level{
+	TraceLevel: zerolog.TraceLevel,
+	DebugLevel: zerolog.DebugLevel,
+	InfoLevel:  zerolog.InfoLevel,
+	WarnLevel:  zerolog.WarnLevel,
+	ErrorLevel: zerolog.ErrorLevel,
+	FatalLevel: zerolog.FatalLevel,
+	PanicLevel: zerolog.PanicLevel,
+}
+
+// zerologBackend encodes entries with zerolog, as JSON or through its
+// console writer for the text format
+type zerologBackend struct {
+	mu     sync.RWMutex
+	writer io.Writer
+	format string
+	logger zerolog.Logger
+}
+
+func newZerologBackend() *zerologBackend {
+	b := &zerologBackend{writer: os.Stderr, format: "json"}
+	b.build()
+	return b
+}
+
+func (b *zerologBackend) Write(entry *Entry) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// WithLevel never exits nor panics, fatal and panic entries are handled by the logger
+	event := b.logger.WithLevel(zerologLevels[entry.Level]).
+		Time(zerolog.TimestampFieldName, entry.Time)
+	if entry.Caller != nil {
+		event = event.Str(callerFileKey, fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)).
+			Str(callerFunctionKey, entry.Caller.Function)
+	}
+	event = event.Fields(map[string]interface{}(entry.Fields))
+
+	// The console writer looks the message up under its own key
+	if b.format == "text" {
+		event.Msg(entry.Message)
+	} else {
+		event.Str(messageKey, entry.Message).Msg("")
+	}
+	return nil
+}
+
+func (b *zerologBackend) SetWriter(writer io.Writer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writer = writer
+	b.build()
+}
+
+func (b *zerologBackend) SetFormat(format string) error {
+	if format != "text" && format != "json" {
+		return unsupportedFormatError(format)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.format = format
+	b.build()
+	return nil
+}
+
+// build creates the logger for the current writer and format, b.mu must be held
+func (b *zerologBackend) build() {
+	writer := b.writer
+	if b.format == "text" {
+		writer = zerolog.ConsoleWriter{Out: writer, NoColor: true, TimeFormat: time.RFC3339}
+	}
+	b.logger = zerolog.New(zerolog.SyncWriter(writer)).Level(zerolog.TraceLevel)
+}
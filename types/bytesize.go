@@ -0,0 +1,97 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize is a size in bytes. It's written as a number of bytes or with a
+// decimal (KB, MB, GB, TB) or binary (KiB, MiB, GiB, TiB) unit, such as
+// "512", "10MB" or "100MiB".
+type ByteSize uint64
+
+const (
+	Byte ByteSize = 1
+
+	KB = 1000 * Byte
+	MB = 1000 * KB
+	GB = 1000 * MB
+	TB = 1000 * GB
+
+	KiB = 1024 * Byte
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"b":   Byte,
+	"k":   KiB,
+	"kb":  KB,
+	"kib": KiB,
+	"m":   MiB,
+	"mb":  MB,
+	"mib": MiB,
+	"g":   GiB,
+	"gb":  GB,
+	"gib": GiB,
+	"t":   TiB,
+	"tb":  TB,
+	"tib": TiB,
+}
+
+// binaryUnits are the units String picks from, largest first
+var binaryUnits = []struct {
+	name string
+	size ByteSize
+}{{"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB}}
+
+// ParseByteSize parses a size such as "100MiB" or "1.5GB". Units are case
+// insensitive, K, M, G and T alone are binary units.
+func ParseByteSize(s string) (ByteSize, error) {
+	trimmed := strings.TrimSpace(s)
+	index := strings.IndexFunc(trimmed, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if index < 0 {
+		index = len(trimmed)
+	}
+
+	number, unit := trimmed[:index], strings.ToLower(strings.TrimSpace(trimmed[index:]))
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("Unknown unit %q in byte size %q", unit, s)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid byte size %q", s)
+	}
+	return ByteSize(value * float64(multiplier)), nil
+}
+
+// String returns the size in the largest binary unit dividing it, such as "100MiB"
+func (b ByteSize) String() string {
+	for _, unit := range binaryUnits {
+		if b >= unit.size && b%unit.size == 0 {
+			return fmt.Sprintf("%d%s", b/unit.size, unit.name)
+		}
+	}
+	return fmt.Sprintf("%dB", uint64(b))
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}